@@ -0,0 +1,114 @@
+package querysql
+
+import (
+	"strings"
+)
+
+// And combines rules with the AND glue, parsed client filters can be used as rules
+func And(rules ...Filter) Filter {
+	return Filter{Glue: "and", Rules: rules}
+}
+
+// Or combines rules with the OR glue
+func Or(rules ...Filter) Filter {
+	return Filter{Glue: "or", Rules: rules}
+}
+
+// Exists creates a rule group which targets the relation
+func Exists(relation string, rules ...Filter) Filter {
+	return Filter{Glue: "and", Relation: relation, Rules: rules}
+}
+
+// FieldBuilder creates rules for a single field
+//
+//	querysql.And(querysql.Field("age").Less(42), querysql.Field("region").In(1, 2, 6))
+type FieldBuilder struct {
+	rule Filter
+}
+
+func Field(name string) FieldBuilder {
+	return FieldBuilder{Filter{Field: name}}
+}
+
+// Count creates a rule which compares the number of related rows
+func Count(relation string) FieldBuilder {
+	return FieldBuilder{Filter{Relation: relation, Aggregate: "count"}}
+}
+
+// Aggregate creates a rule which compares the aggregate of the field of related rows
+func Aggregate(relation string, aggregate string, field string) FieldBuilder {
+	return FieldBuilder{Filter{Relation: relation, Aggregate: aggregate, Field: field}}
+}
+
+// Ref is a value which points to another field
+func Ref(field string) map[string]interface{} {
+	return map[string]interface{}{"field": field}
+}
+
+func (b FieldBuilder) Type(tp string) FieldBuilder {
+	b.rule.Type = tp
+	return b
+}
+
+func (b FieldBuilder) Predicate(name string) FieldBuilder {
+	b.rule.Predicate = name
+	b.rule.PredicateArgs = nil
+	return b
+}
+
+// PredicateWith adds the predicate with arguments to the chain of predicates
+//
+//	querysql.Field("created").PredicateWith("trunc", "week").Equal(monday)
+func (b FieldBuilder) PredicateWith(name string, args ...interface{}) FieldBuilder {
+	chain := append(predicateChain(b.rule.Predicate), name)
+	predicateArgs := make([][]interface{}, len(chain))
+	for i := range chain[:len(chain)-1] {
+		predicateArgs[i] = b.rule.predicateArgs(i)
+	}
+	predicateArgs[len(chain)-1] = args
+
+	b.rule.Predicate = strings.Join(chain, "|")
+	b.rule.PredicateArgs = predicateArgs
+	return b
+}
+
+// Is creates a rule with any operation, including custom ones
+func (b FieldBuilder) Is(operation string, value interface{}) Filter {
+	rule := b.rule
+	rule.Filter = operation
+	rule.Value = value
+	return rule
+}
+
+func (b FieldBuilder) Equal(v interface{}) Filter          { return b.Is("equal", v) }
+func (b FieldBuilder) NotEqual(v interface{}) Filter       { return b.Is("notEqual", v) }
+func (b FieldBuilder) Less(v interface{}) Filter           { return b.Is("less", v) }
+func (b FieldBuilder) LessOrEqual(v interface{}) Filter    { return b.Is("lessOrEqual", v) }
+func (b FieldBuilder) Greater(v interface{}) Filter        { return b.Is("greater", v) }
+func (b FieldBuilder) GreaterOrEqual(v interface{}) Filter { return b.Is("greaterOrEqual", v) }
+func (b FieldBuilder) Contains(v interface{}) Filter       { return b.Is("contains", v) }
+func (b FieldBuilder) NotContains(v interface{}) Filter    { return b.Is("notContains", v) }
+func (b FieldBuilder) BeginsWith(v interface{}) Filter     { return b.Is("beginsWith", v) }
+func (b FieldBuilder) NotBeginsWith(v interface{}) Filter  { return b.Is("notBeginsWith", v) }
+func (b FieldBuilder) EndsWith(v interface{}) Filter       { return b.Is("endsWith", v) }
+func (b FieldBuilder) NotEndsWith(v interface{}) Filter    { return b.Is("notEndsWith", v) }
+
+// Between creates the between rule, nil start or end makes the range open
+func (b FieldBuilder) Between(start, end interface{}) Filter {
+	return b.Is("between", rangeValue(start, end))
+}
+
+func (b FieldBuilder) NotBetween(start, end interface{}) Filter {
+	return b.Is("notBetween", rangeValue(start, end))
+}
+
+// In creates the includes rule, the rule without values matches no rows
+func (b FieldBuilder) In(values ...interface{}) Filter {
+	rule := b.rule
+	rule.Includes = append(make([]interface{}, 0, len(values)), values...)
+	return rule
+}
+
+func rangeValue(start, end interface{}) map[string]interface{} {
+	return map[string]interface{}{"start": start, "end": end}
+}
@@ -0,0 +1,61 @@
+package querysql
+
+import (
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	client, err := FromJSON([]byte(aOrB))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", aOrB, err)
+		return
+	}
+
+	cases := []struct {
+		filter Filter
+		check  string
+	}{
+		{
+			And(Field("age").Less(42), Field("region").In(1, 2, 6)),
+			"( age < $1 AND region IN($2,$3,$4) )",
+		},
+		{
+			Or(Field("d").Between(1, 2), Field("d").NotBetween(nil, 5), Field("name").BeginsWith("A")),
+			"( ( d > $1 AND d < $2 ) OR d > $3 OR name LIKE $4 || '%' )",
+		},
+		{
+			And(client, Field("deleted").Equal(false), Field("shipped").Greater(Ref("ordered"))),
+			"( ( a < $1 OR b > $2 ) AND deleted = $3 AND shipped > ordered )",
+		},
+		{
+			And(Exists("items", Field("sku").Equal("X")), Count("items").GreaterOrEqual(2)),
+			"( EXISTS (SELECT 1 FROM items WHERE sku = $1) AND (SELECT COUNT(*) FROM items) >= $2 )",
+		},
+		{
+			Or(Field("region").In(), Field("age").Less(42)),
+			"( 1 = 0 OR age < $1 )",
+		},
+		{
+			Field("created").Predicate("year").Type(TypeDate).Is("equal", 2024),
+			"year(created) = $1",
+		},
+	}
+
+	config := &SQLConfig{
+		Relations: map[string]Relation{"items": {Table: "items", Config: &SQLConfig{Whitelist: map[string]bool{"sku": true}}}},
+		Predicates: map[string]CustomPredicate{
+			"year": func(n string, p string) (string, error) { return "year(" + n + ")", nil },
+		},
+	}
+
+	for _, c := range cases {
+		sql, _, err := GetSQL(c.filter, config, PostgreSQL{})
+		if err != nil {
+			t.Errorf("can't generate sql\nf: %+v\n%s", c.filter, err)
+			continue
+		}
+		if sql != c.check {
+			t.Errorf("wrong sql generated\ns: %s\nr: %s", c.check, sql)
+		}
+	}
+}
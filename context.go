@@ -0,0 +1,31 @@
+package querysql
+
+import (
+	"context"
+)
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx which carries the principal for policies
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// GetSQLContext renders the filter passing ctx to the context variants of hooks,
+// the principal stored by WithPrincipal is used for policies
+func GetSQLContext(ctx context.Context, data Filter, config *SQLConfig, dbArr ...DBDriver) (string, []interface{}, error) {
+	var db DBDriver
+	if len(dbArr) > 0 {
+		db = dbArr[0]
+	}
+
+	rc := NewRenderContext(config, db)
+	rc.Ctx = ctx
+	rc.Principal = PrincipalFromContext(ctx)
+	return rc.GetSQL(data)
+}
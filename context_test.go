@@ -0,0 +1,63 @@
+package querysql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type localeKey struct{}
+
+func TestGetSQLContext(t *testing.T) {
+	config := &SQLConfig{
+		WhitelistFuncContext: func(ctx context.Context, name string) bool {
+			return PrincipalFromContext(ctx).HasRole("admin") || name == "a"
+		},
+		OperationsContext: map[string]CustomOperationContext{
+			"search": func(ctx context.Context, n string, r string, values []interface{}) (string, []interface{}, error) {
+				return fmt.Sprintf("to_tsvector('%s', %s) @@ ?", ctx.Value(localeKey{}), n), values, nil
+			},
+		},
+		PredicatesContext: map[string]CustomPredicateContext{
+			"local": func(ctx context.Context, n string, p string) (string, error) {
+				return fmt.Sprintf("%s AT TIME ZONE '%s'", n, ctx.Value(localeKey{})), nil
+			},
+		},
+	}
+
+	text := `{ "glue":"and", "rules":[{ "field": "a", "filter":"search", "value":"x" }, { "field": "b", "predicate":"local", "filter":"less", "value":1 }]}`
+	format, _ := FromJSON([]byte(text))
+
+	ctx := context.WithValue(context.Background(), localeKey{}, "english")
+	if _, _, err := GetSQLContext(ctx, format, config); err == nil {
+		t.Errorf("doesn't check whitelist with context\nj: %s", text)
+	}
+
+	ctx = WithPrincipal(ctx, &Principal{Roles: []string{"admin"}})
+	sql, _, err := GetSQLContext(ctx, format, config)
+	if err != nil {
+		t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+		return
+	}
+
+	check := "( to_tsvector('english', a) @@ ? AND b AT TIME ZONE 'english' < ? )"
+	if sql != check {
+		t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", text, check, sql)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := GetSQLContext(canceled, format, config); err != context.Canceled {
+		t.Errorf("doesn't stop on canceled context\nr: %v", err)
+	}
+
+	// old hooks still work with GetSQLContext
+	format, _ = FromJSON([]byte(aPred))
+	_, _, err = GetSQLContext(ctx, format, &SQLConfig{Predicates: map[string]CustomPredicate{
+		"month": func(n string, p string) (string, error) { return n, nil },
+		"year":  func(n string, p string) (string, error) { return n, nil },
+	}})
+	if err != nil {
+		t.Errorf("can't generate sql with old hooks\nj: %s\n%s", aPred, err)
+	}
+}
@@ -0,0 +1,166 @@
+package querysql
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cursorData is stored in the cursor token, Sort allows to reject
+// the cursor of a query with a different sort order
+type cursorData struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// TupleComparer is an optional interface of DBDriver, drivers which return true
+// from TupleComparison compare row values of cursors as "(a, b) > ($1, $2)",
+// other drivers use an expanded OR chain
+type TupleComparer interface {
+	TupleComparison() bool
+}
+
+func (m PostgreSQL) TupleComparison() bool {
+	return true
+}
+
+func sortKey(sort []Sort) string {
+	out := make([]string, len(sort))
+	for i, s := range sort {
+		dir := s.Dir
+		if dir == "" {
+			dir = "asc"
+		}
+		out[i] = s.Field + ":" + dir
+	}
+	return strings.Join(out, ",")
+}
+
+// NextCursor returns the token of the page which follows the row with the given values
+// of the sort fields, the values must be in the order of q.Sort and can't be NULL.
+// The last sort field should be unique, otherwise rows with equal values can be skipped.
+func (q Query) NextCursor(values ...interface{}) (string, error) {
+	if len(q.Sort) == 0 {
+		return "", fmt.Errorf("cursor requires sort fields")
+	}
+	if len(values) != len(q.Sort) {
+		return "", fmt.Errorf("cursor expects %d values, got %d", len(q.Sort), len(values))
+	}
+
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		switch x := v.(type) {
+		case nil:
+			return "", fmt.Errorf("cursor value of %s is null", q.Sort[i].Field)
+		case time.Time:
+			out[i] = x.Format(time.RFC3339Nano)
+		default:
+			out[i] = v
+		}
+	}
+
+	text, err := json.Marshal(cursorData{Sort: sortKey(q.Sort), Values: out})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(text), nil
+}
+
+// decodeCursor returns the values of the cursor converted to the types of the schema
+func decodeCursor(token string, sort []Sort, config *SQLConfig) ([]interface{}, error) {
+	text, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, cursorError("wrong cursor")
+	}
+
+	var data cursorData
+	dec := json.NewDecoder(bytes.NewReader(text))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, cursorError("wrong cursor")
+	}
+	if data.Sort != sortKey(sort) || len(data.Values) != len(sort) {
+		return nil, cursorError("cursor doesn't match the sort order")
+	}
+
+	for i, v := range data.Values {
+		if v == nil {
+			return nil, cursorError("cursor value of %s is null", sort[i].Field)
+		}
+		if n, ok := v.(json.Number); ok {
+			if x, err := n.Int64(); err == nil {
+				v = x
+			} else if x, err := n.Float64(); err == nil {
+				v = x
+			}
+		}
+		if fs, ok := getFieldSchema(sort[i].Field, config); ok {
+			if cv, ok := coerceValue(v, fs.Type); ok {
+				v = cv
+			}
+		}
+		data.Values[i] = v
+	}
+
+	return data.Values, nil
+}
+
+func cursorError(format string, args ...interface{}) error {
+	err := ruleError(ErrBadValue, "", format, args...)
+	err.Path = "cursor"
+	return err
+}
+
+// keysetSQL returns the condition which selects the rows after the cursor, a tuple comparison
+// is used when the driver supports it and all fields are sorted in the same direction
+func (rc *RenderContext) keysetSQL(names []string, sort []Sort, values []interface{}) (string, []interface{}) {
+	desc := sort[0].Dir == "desc"
+	same := true
+	for _, s := range sort {
+		if (s.Dir == "desc") != desc {
+			same = false
+		}
+	}
+
+	if tc, ok := rc.DB.(TupleComparer); ok && tc.TupleComparison() && same && len(sort) > 1 {
+		marks := make([]string, len(values))
+		for i := range marks {
+			marks[i] = rc.Mark()
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(names, ", "), keysetOperator(desc), strings.Join(marks, ", ")), values
+	}
+
+	// ( a > ? OR ( a = ? AND b > ? ) )
+	parts := make([]string, len(names))
+	out := make([]interface{}, 0)
+	for i := range names {
+		conds := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conds = append(conds, fmt.Sprintf("%s = %s", names[j], rc.Mark()))
+			out = append(out, values[j])
+		}
+		conds = append(conds, fmt.Sprintf("%s %s %s", names[i], keysetOperator(sort[i].Dir == "desc"), rc.Mark()))
+		out = append(out, values[i])
+
+		if len(conds) > 1 {
+			parts[i] = "( " + strings.Join(conds, " AND ") + " )"
+		} else {
+			parts[i] = conds[0]
+		}
+	}
+
+	if len(parts) == 1 {
+		return parts[0], out
+	}
+	return "( " + strings.Join(parts, " OR ") + " )", out
+}
+
+func keysetOperator(desc bool) string {
+	if desc {
+		return "<"
+	}
+	return ">"
+}
@@ -0,0 +1,99 @@
+package querysql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// tupleDriver is a custom driver which supports tuple comparison
+type tupleDriver struct {
+	pageDriver
+}
+
+func (d tupleDriver) TupleComparison() bool {
+	return true
+}
+
+func TestCursor(t *testing.T) {
+	config := &SQLConfig{
+		Schema: map[string]FieldSchema{
+			"a":  {Type: TypeNumber},
+			"id": {Type: TypeNumber},
+			"d":  {Type: TypeDate},
+		},
+	}
+
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	filter := Field("a").Less(42)
+
+	checks := []struct {
+		sort   []Sort
+		last   []interface{}
+		db     DBDriver
+		sql    string
+		values []interface{}
+	}{
+		{[]Sort{{Field: "id"}}, []interface{}{10}, PostgreSQL{},
+			"WHERE ( a < $1 ) AND id > $2 ORDER BY id ASC LIMIT 20", []interface{}{42.0, int64(10)}},
+		{[]Sort{{Field: "d", Dir: "desc"}, {Field: "id", Dir: "desc"}}, []interface{}{day, 10}, PostgreSQL{},
+			"WHERE ( a < $1 ) AND (d, id) < ($2, $3) ORDER BY d DESC, id DESC LIMIT 20", []interface{}{42.0, day, int64(10)}},
+		{[]Sort{{Field: "d", Dir: "desc"}, {Field: "id"}}, []interface{}{day, 10}, PostgreSQL{},
+			"WHERE ( a < $1 ) AND ( d < $2 OR ( d = $3 AND id > $4 ) ) ORDER BY d DESC, id ASC LIMIT 20", []interface{}{42.0, day, day, int64(10)}},
+		{[]Sort{{Field: "d", Dir: "desc"}, {Field: "id", Dir: "desc"}}, []interface{}{day, 10}, MySQL{},
+			"WHERE ( a < ? ) AND ( d < ? OR ( d = ? AND id < ? ) ) ORDER BY d DESC, id DESC LIMIT 20", []interface{}{42.0, day, day, int64(10)}},
+		{[]Sort{{Field: "d", Dir: "desc"}, {Field: "id", Dir: "desc"}}, []interface{}{day, 10}, pageDriver{customDriver{PostgreSQL{}}},
+			"WHERE ( a < $1 ) AND ( d < $2 OR ( d = $3 AND id < $4 ) ) ORDER BY d DESC, id DESC LIMIT 20", []interface{}{42.0, day, day, int64(10)}},
+		{[]Sort{{Field: "d", Dir: "desc"}, {Field: "id", Dir: "desc"}}, []interface{}{day, 10}, tupleDriver{pageDriver{customDriver{MySQL{}}}},
+			"WHERE ( a < ? ) AND (d, id) < (?, ?) ORDER BY d DESC, id DESC LIMIT 20", []interface{}{42.0, day, int64(10)}},
+	}
+
+	for _, c := range checks {
+		q := Query{Filter: filter, Sort: c.sort, Limit: 20}
+		cursor, err := q.NextCursor(c.last...)
+		if err != nil {
+			t.Errorf("can't create cursor\n%s", err)
+			continue
+		}
+
+		q.Cursor = cursor
+		out, err := GetQuery(q, config, c.db)
+		if err != nil {
+			t.Errorf("can't generate sql\nq: %+v\n%s", q, err)
+			continue
+		}
+		if out.String() != c.sql {
+			t.Errorf("wrong sql generated\ns: %s\nr: %s", c.sql, out.String())
+		}
+		if fmt.Sprint(out.Values) != fmt.Sprint(c.values) {
+			t.Errorf("wrong values generated\ns: %v\nr: %v", c.values, out.Values)
+		}
+	}
+
+	q := Query{Sort: []Sort{{Field: "id"}}}
+	cursor, _ := q.NextCursor(10)
+	out, err := GetQuery(Query{Sort: q.Sort, Cursor: cursor}, &SQLConfig{NamedParams: &NamedParams{}}, PostgreSQL{})
+	if err != nil || out.String() != "WHERE id > :p1 ORDER BY id ASC" {
+		t.Errorf("wrong sql generated with named parameters\nr: %s %v", out.String(), err)
+	}
+	if _, err := NamedValues(out.Values); err != nil {
+		t.Errorf("cursor values are not named: %s", err)
+	}
+
+	errs := []Query{
+		{Sort: []Sort{{Field: "id", Dir: "desc"}}, Cursor: cursor},
+		{Sort: []Sort{{Field: "id"}}, Cursor: "not a cursor"},
+		{Sort: []Sort{{Field: "id"}}, Cursor: cursor, Offset: 10},
+		{Sort: []Sort{{Field: "id", Nulls: "last"}}, Cursor: cursor},
+	}
+	for _, q := range errs {
+		if _, err := GetQuery(q, config); !errors.Is(err, ErrBadValue) {
+			t.Errorf("wrong cursor is accepted\nq: %+v\nr: %v", q, err)
+		}
+	}
+
+	if _, err := q.NextCursor(nil); err == nil {
+		t.Errorf("cursor accepts null values")
+	}
+}
@@ -0,0 +1,94 @@
+package querysql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DebugSQL renders the filter with values inlined as SQL literals of the driver.
+// The result is intended for logging only, never execute it, use GetSQL for queries.
+func DebugSQL(data Filter, config *SQLConfig, dbArr ...DBDriver) (string, error) {
+	var db DBDriver
+	if len(dbArr) > 0 {
+		db = dbArr[0]
+	} else {
+		db = MySQL{}
+	}
+
+	rc := NewRenderContext(config, db)
+	rc.inline = true
+
+	first := rc.index()
+	sql, values, err := rc.GetSQL(data)
+	if err != nil {
+		return "", err
+	}
+	if len(values) != rc.index()-first {
+		return "", fmt.Errorf("number of values doesn't match number of placeholders: %d != %d", len(values), rc.index()-first)
+	}
+
+	// placeholders are rendered as \x00index\x00
+	parts := strings.Split(sql, "\x00")
+	out := strings.Builder{}
+	for i, p := range parts {
+		if i%2 == 0 {
+			out.WriteString(p)
+			continue
+		}
+
+		index, err := strconv.Atoi(p)
+		if err != nil || index < first || index-first >= len(values) {
+			return "", fmt.Errorf("wrong placeholder in generated sql: %q", p)
+		}
+		out.WriteString(db.Literal(values[index-first]))
+	}
+
+	return out.String(), nil
+}
+
+func inlineMark(index int) string {
+	return "\x00" + strconv.Itoa(index) + "\x00"
+}
+
+// literalFormat formats common values, quoting of strings, bytes and time are specific to the dialect
+type literalFormat struct {
+	quote func(string) string
+	bytes func([]byte) string
+	time  string
+}
+
+func (f literalFormat) format(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case string:
+		return f.quote(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", x)
+	case json.Number:
+		if _, err := x.Float64(); err == nil {
+			return x.String()
+		}
+		return f.quote(x.String())
+	case time.Time:
+		return f.quote(x.Format(f.time))
+	case []byte:
+		return f.bytes(x)
+	case fmt.Stringer:
+		return f.quote(x.String())
+	}
+
+	return f.quote(fmt.Sprintf("%v", v))
+}
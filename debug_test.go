@@ -0,0 +1,54 @@
+package querysql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebugSQL(t *testing.T) {
+	text := `{ "glue":"and", "rules":[{ "field": "a", "filter":"less", "value":1.5 }, { "field": "b", "filter":"contains", "value":"it's \\ ok" }, { "field": "c", "includes":[true, null, 3] }]}`
+	format, err := FromJSON([]byte(text))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", text, err)
+		return
+	}
+
+	cases := []struct {
+		db    DBDriver
+		check string
+	}{
+		{MySQL{}, `( a < 1.5 AND INSTR(b, 'it\'s \\ ok') > 0 AND c IN(TRUE,NULL,3) )`},
+		{PostgreSQL{}, `( a < 1.5 AND b LIKE '%' || 'it''s \ ok' || '%' AND c IN(TRUE,NULL,3) )`},
+	}
+
+	for _, c := range cases {
+		sql, err := DebugSQL(format, &SQLConfig{NamedParams: &NamedParams{}}, c.db)
+		if err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+			continue
+		}
+		if sql != c.check {
+			t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", text, c.check, sql)
+		}
+	}
+
+	day := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	literals := []struct {
+		db    DBDriver
+		value interface{}
+		check string
+	}{
+		{MySQL{}, day, "'2024-01-02 03:04:05'"},
+		{PostgreSQL{}, day, "'2024-01-02 03:04:05+00:00'"},
+		{MySQL{}, []byte("ab"), "X'6162'"},
+		{PostgreSQL{}, []byte("ab"), `'\x6162'::bytea`},
+		{MySQL{}, int64(-7), "-7"},
+		{MySQL{}, "a\nb", `'a\nb'`},
+	}
+
+	for _, l := range literals {
+		if out := l.db.Literal(l.value); out != l.check {
+			t.Errorf("wrong literal generated\ns: %s\nr: %s", l.check, out)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package querysql
+
+const (
+	DialectMySQL      = "mysql"
+	DialectPostgreSQL = "postgresql"
+)
+
+// DialectConfig contains operations and predicates which are used only with
+// the driver of the given dialect, they take precedence over the hooks of SQLConfig
+type DialectConfig struct {
+	Operations map[string]RuleOperation
+	Predicates map[string]RulePredicate
+}
+
+func getDialect(config *SQLConfig, db DBDriver) (DialectConfig, bool) {
+	if config == nil || config.Dialects == nil {
+		return DialectConfig{}, false
+	}
+
+	d, ok := config.Dialects[db.Dialect()]
+	return d, ok
+}
@@ -0,0 +1,79 @@
+package querysql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDialects(t *testing.T) {
+	config := &SQLConfig{
+		RulePredicates: map[string]RulePredicate{
+			"lower": func(rc *RenderContext, p PredicateCall) (string, []interface{}, error) {
+				return fmt.Sprintf("LOWER(%s)", p.Field), NoValues, nil
+			},
+			"year": func(rc *RenderContext, p PredicateCall) (string, []interface{}, error) {
+				return fmt.Sprintf("YEAR(%s)", p.Field), NoValues, nil
+			},
+		},
+		Dialects: map[string]DialectConfig{
+			DialectPostgreSQL: {
+				Predicates: map[string]RulePredicate{
+					"year": func(rc *RenderContext, p PredicateCall) (string, []interface{}, error) {
+						return fmt.Sprintf("EXTRACT(YEAR FROM %s)", p.Field), NoValues, nil
+					},
+				},
+				Operations: map[string]RuleOperation{
+					"like": func(rc *RenderContext, op OperationCall) (string, []interface{}, error) {
+						return fmt.Sprintf("%s ILIKE %s", op.Field, rc.Mark()), op.Values, nil
+					},
+				},
+			},
+		},
+		RuleOperations: map[string]RuleOperation{
+			"like": func(rc *RenderContext, op OperationCall) (string, []interface{}, error) {
+				return fmt.Sprintf("%s LIKE %s", op.Field, rc.Mark()), op.Values, nil
+			},
+		},
+	}
+
+	text := `{ "glue":"and", "rules":[{ "field": "a", "predicate":"year", "filter":"equal", "value":2024 }, { "field": "b", "predicate":"lower", "filter":"like", "value":"x%" }, { "field": "c", "filter":"less", "value":1 }]}`
+	format, err := FromJSON([]byte(text))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", text, err)
+		return
+	}
+
+	checks := []struct {
+		db  DBDriver
+		sql string
+	}{
+		{MySQL{}, "( YEAR(a) = ? AND LOWER(b) LIKE ? AND c < ? )"},
+		{PostgreSQL{}, "( EXTRACT(YEAR FROM a) = $1 AND LOWER(b) ILIKE $2 AND c < $3 )"},
+	}
+
+	for _, c := range checks {
+		sql, _, err := GetSQL(format, config, c.db)
+		if err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+			continue
+		}
+		if sql != c.sql {
+			t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", text, c.sql, sql)
+		}
+	}
+
+	pgOnly := &SQLConfig{Dialects: map[string]DialectConfig{
+		DialectPostgreSQL: {Predicates: config.Dialects[DialectPostgreSQL].Predicates},
+	}}
+	format, _ = FromJSON([]byte(`{ "field": "a", "predicate":"year", "filter":"equal", "value":2024 }`))
+	if errs := Validate(format, pgOnly, PostgreSQL{}); errs != nil {
+		t.Errorf("dialect predicate is not valid: %v", errs)
+	}
+	if _, _, err := GetSQL(format, pgOnly, MySQL{}); !errors.Is(err, ErrUnknownPredicate) {
+		t.Errorf("predicate of other dialect is ignored: %v", err)
+	}
+	if errs := Validate(format, pgOnly); len(errs) != 1 || !errors.Is(errs[0], ErrUnknownPredicate) {
+		t.Errorf("predicate of other dialect is valid: %v", errs)
+	}
+}
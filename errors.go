@@ -0,0 +1,68 @@
+package querysql
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrFieldNotAllowed     = errors.New("field name is not in whitelist")
+	ErrUnknownOperation    = errors.New("unknown operation")
+	ErrOperationNotAllowed = errors.New("operation is not allowed")
+	ErrUnknownPredicate    = errors.New("unknown predicate")
+	ErrPredicateNotAllowed = errors.New("predicate is not allowed")
+	ErrUnknownRelation     = errors.New("unknown relation")
+	ErrBadValue            = errors.New("bad value")
+	ErrBadRule             = errors.New("bad rule")
+	ErrLimitExceeded       = errors.New("filter exceeds limit")
+)
+
+// RuleError describes an invalid rule of the filter.
+// Path points to the rule in the JSON tree, e.g. "rules[1].rules[0]", it is empty for the root rule.
+// Err is one of the Err* values or the error returned by a custom operation or predicate.
+type RuleError struct {
+	Path   string
+	Field  string
+	Err    error
+	Detail string
+}
+
+func (e *RuleError) Error() string {
+	msg := e.Err.Error()
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	return msg
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+func ruleError(err error, field string, format string, args ...interface{}) *RuleError {
+	return &RuleError{Field: field, Err: err, Detail: fmt.Sprintf(format, args...)}
+}
+
+// withPath sets the path of the rule which caused the error,
+// errors of custom operations and predicates are wrapped into RuleError
+func withPath(err error, path string, field string) error {
+	var re *RuleError
+	if errors.As(err, &re) {
+		if re.Path == "" {
+			re.Path = path
+		}
+		return re
+	}
+
+	return &RuleError{Path: path, Field: field, Err: err}
+}
+
+func childPath(path string, index int) string {
+	if path == "" {
+		return fmt.Sprintf("rules[%d]", index)
+	}
+	return fmt.Sprintf("%s.rules[%d]", path, index)
+}
@@ -0,0 +1,62 @@
+package querysql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRuleError(t *testing.T) {
+	config := &SQLConfig{
+		Whitelist: map[string]bool{"a": true, "b": true},
+		Relations: map[string]Relation{"items": {Table: "items", Config: &SQLConfig{Whitelist: map[string]bool{"sku": true}}}},
+	}
+
+	cases := []struct {
+		text string
+		err  error
+		path string
+		msg  string
+	}{
+		{`{ "field": "x", "filter":"equal", "value":1 }`, ErrFieldNotAllowed, "", "field name is not in whitelist: x"},
+		{`{ "glue":"and", "rules":[{ "field": "a", "filter":"less", "value":1 }, { "glue":"or", "rules":[{ "field": "b", "filter":"like", "value":1 }]}]}`, ErrUnknownOperation, "rules[1].rules[0]", "rules[1].rules[0]: unknown operation: like"},
+		{`{ "glue":"and", "rules":[{ "field": "a", "filter":"between", "value":1 }]}`, ErrBadValue, "rules[0]", ""},
+		{`{ "glue":"and", "rules":[{ "relation":"orders", "rules":[] }]}`, ErrUnknownRelation, "rules[0]", "rules[0]: unknown relation: orders"},
+		{`{ "glue":"and", "rules":[{ "field": "a", "filter":"less", "value":1 }, { "relation":"items", "rules":[{ "field": "sku", "filter":"less", "value":1 }, { "field": "a", "filter":"less", "value":1 }]}]}`, ErrFieldNotAllowed, "rules[1].rules[1]", ""},
+		{`{ "glue":"and", "rules":[{ "field": "a", "filter":"less", "value":{ "field":"c" } }]}`, ErrFieldNotAllowed, "rules[0]", ""},
+	}
+
+	for _, c := range cases {
+		format, err := FromJSON([]byte(c.text))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", c.text, err)
+			continue
+		}
+
+		_, _, err = GetSQL(format, config)
+		if !errors.Is(err, c.err) {
+			t.Errorf("wrong error returned\nj: %s\ns: %s\nr: %v", c.text, c.err, err)
+			continue
+		}
+
+		var re *RuleError
+		if !errors.As(err, &re) || re.Path != c.path {
+			t.Errorf("wrong error path\nj: %s\ns: %s\nr: %v", c.text, c.path, err)
+			continue
+		}
+
+		if c.msg != "" && err.Error() != c.msg {
+			t.Errorf("wrong error message\nj: %s\ns: %s\nr: %s", c.text, c.msg, err)
+		}
+	}
+
+	custom := errors.New("custom")
+	format, _ := FromJSON([]byte(`{ "glue":"and", "rules":[{ "field": "a", "filter":"fail" }]}`))
+	_, _, err := GetSQL(format, &SQLConfig{Operations: map[string]CustomOperation{
+		"fail": func(n string, f string, v []interface{}) (string, []interface{}, error) { return "", nil, custom },
+	}})
+
+	var re *RuleError
+	if !errors.Is(err, custom) || !errors.As(err, &re) || re.Path != "rules[0]" || re.Field != "a" {
+		t.Errorf("custom operation error is not wrapped\nr: %v", err)
+	}
+}
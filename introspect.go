@@ -0,0 +1,143 @@
+package querysql
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Queryer is implemented by *sql.DB, *sql.Tx and wrappers around them
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// SchemaReader is an optional interface of DBDriver which is required by FromDatabase.
+// ColumnsQuery receives the table name as the only parameter and returns rows of
+// column_name, data_type, is_nullable. JSONColumnsQuery returns names of JSONB columns
+// of the table, empty query means that the database has no such columns.
+type SchemaReader interface {
+	ColumnsQuery() string
+	JSONColumnsQuery() string
+}
+
+func (m MySQL) ColumnsQuery() string {
+	return "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+}
+
+func (m MySQL) JSONColumnsQuery() string {
+	return ""
+}
+
+func (m PostgreSQL) ColumnsQuery() string {
+	return "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position"
+}
+
+func (m PostgreSQL) JSONColumnsQuery() string {
+	return "SELECT a.attname FROM pg_catalog.pg_attribute a " +
+		"JOIN pg_catalog.pg_class c ON c.oid = a.attrelid " +
+		"JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace " +
+		"WHERE n.nspname = current_schema() AND c.relname = $1 AND a.atttypid = 'pg_catalog.jsonb'::pg_catalog.regtype " +
+		"AND a.attnum > 0 AND NOT a.attisdropped ORDER BY a.attnum"
+}
+
+var numericTypes = map[string]bool{
+	"tinyint": true, "smallint": true, "mediumint": true, "int": true, "integer": true, "bigint": true,
+	"decimal": true, "numeric": true, "real": true, "float": true,
+}
+
+var jsonKey = regexp.MustCompile(`^[A-Za-z0-9_]+(:[A-Za-z]+)?$`)
+
+// FromDatabase builds SQLConfig from the columns of the table.
+// Keys of PostgreSQL JSONB columns are allowed through the json:column.key syntax.
+func FromDatabase(db Queryer, dialect DBDriver, table string) (*SQLConfig, error) {
+	reader, ok := dialect.(SchemaReader)
+	if !ok {
+		return nil, fmt.Errorf("database driver doesn't support introspection: %T", dialect)
+	}
+
+	config := &SQLConfig{Schema: make(map[string]FieldSchema)}
+
+	rows, err := db.Query(reader.ColumnsQuery(), table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, dataType, nullable string
+		if err := rows.Scan(&name, &dataType, &nullable); err != nil {
+			return nil, err
+		}
+
+		tp, ok := columnType(dataType)
+		if !ok {
+			continue
+		}
+		config.Schema[name] = FieldSchema{Type: tp, Nullable: nullable == "YES"}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(config.Schema) == 0 {
+		return nil, fmt.Errorf("table not found: %s", table)
+	}
+
+	if reader.JSONColumnsQuery() == "" {
+		return config, nil
+	}
+
+	jsonColumns, err := readJSONColumns(db, reader.JSONColumnsQuery(), table)
+	if err != nil {
+		return nil, err
+	}
+	if len(jsonColumns) > 0 {
+		config.WhitelistFunc = func(name string) bool {
+			for _, c := range jsonColumns {
+				if strings.HasPrefix(name, "json:"+c+".") && jsonKey.MatchString(name[len(c)+6:]) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	return config, nil
+}
+
+func readJSONColumns(db Queryer, query, table string) ([]string, error) {
+	rows, err := db.Query(query, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+
+	return out, rows.Err()
+}
+
+// columnType converts the information_schema data type, JSON columns can't be compared directly
+func columnType(dataType string) (string, bool) {
+	dataType = strings.ToLower(dataType)
+	switch {
+	case dataType == "json" || dataType == "jsonb":
+		return "", false
+	case dataType == "boolean" || dataType == "bool":
+		return TypeBoolean, true
+	case dataType == "date" || dataType == "datetime" || strings.HasPrefix(dataType, "timestamp"):
+		return TypeDate, true
+	case numericTypes[dataType] || strings.HasPrefix(dataType, "double"):
+		return TypeNumber, true
+	}
+
+	return TypeText, true
+}
@@ -0,0 +1,134 @@
+package querysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeDriver returns prepared rows for queries which contain the key of fakeTables
+type fakeDriver struct{}
+type fakeConn struct{}
+type fakeStmt struct{ query string }
+type fakeRows struct {
+	columns []string
+	data    [][]driver.Value
+}
+
+var fakeTables = map[string][][]driver.Value{
+	"information_schema.columns": {
+		{"id", "integer", "NO"},
+		{"name", "character varying", "YES"},
+		{"created", "timestamp without time zone", "NO"},
+		{"active", "boolean", "NO"},
+		{"price", "double precision", "YES"},
+		{"cfg", "jsonb", "YES"},
+	},
+	"pg_catalog.pg_attribute": {
+		{"cfg"},
+	},
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) { return fakeConn{}, nil }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if len(args) != 1 || args[0] != "users" {
+		return &fakeRows{columns: []string{"column_name"}}, nil
+	}
+
+	for key, data := range fakeTables {
+		if strings.Contains(s.query, key) {
+			return &fakeRows{columns: make([]string, len(data[0])), data: data}, nil
+		}
+	}
+	return &fakeRows{columns: []string{"column_name"}}, nil
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.data[0])
+	r.data = r.data[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("querysqlfake", fakeDriver{})
+}
+
+// readerDriver is a custom driver which supports introspection
+type readerDriver struct {
+	DBDriver
+}
+
+func (d readerDriver) ColumnsQuery() string {
+	return "SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_name = ?"
+}
+
+func (d readerDriver) JSONColumnsQuery() string {
+	return ""
+}
+
+func TestFromDatabase(t *testing.T) {
+	db, err := sql.Open("querysqlfake", "")
+	if err != nil {
+		t.Errorf("can't open database\n%s", err)
+		return
+	}
+	defer db.Close()
+
+	config, err := FromDatabase(db, &PostgreSQL{}, "users")
+	if err != nil {
+		t.Errorf("can't read table\n%s", err)
+		return
+	}
+
+	types := map[string]string{"id": TypeNumber, "name": TypeText, "created": TypeDate, "active": TypeBoolean, "price": TypeNumber}
+	if len(config.Schema) != len(types) {
+		t.Errorf("wrong number of fields\ns: %+v\nr: %+v", types, config.Schema)
+	}
+	for name, tp := range types {
+		if config.Schema[name].Type != tp {
+			t.Errorf("wrong type of %s\ns: %s\nr: %s", name, tp, config.Schema[name].Type)
+		}
+	}
+	if config.Schema["id"].Nullable || !config.Schema["name"].Nullable {
+		t.Errorf("wrong nullable flags\nr: %+v", config.Schema)
+	}
+
+	for name, allowed := range map[string]bool{
+		"id": true, "json:cfg.a": true, "json:cfg.b:numeric": true, "cfg": false, "other": false,
+		"json:cfg.a')::text OR 1=1 --": false, "json:cfgx.a": false,
+	} {
+		if checkWhitelist(context.Background(), name, config) != allowed {
+			t.Errorf("wrong whitelist check of %s\ns: %t", name, allowed)
+		}
+	}
+
+	if _, err := FromDatabase(db, MySQL{}, "orders"); err == nil {
+		t.Errorf("doesn't return error for a missing table")
+	}
+
+	custom, err := FromDatabase(db, readerDriver{MySQL{}}, "users")
+	if err != nil || custom.Schema["price"].Type != TypeNumber {
+		t.Errorf("custom driver can't read the schema: %v", err)
+	}
+	if _, err := FromDatabase(db, customDriver{MySQL{}}, "users"); err == nil {
+		t.Errorf("driver without SchemaReader is accepted")
+	}
+}
@@ -0,0 +1,101 @@
+package querysql
+
+// Limits restricts the complexity of the filter, zero value of a field disables the check.
+// Depth is the nesting level of rule groups, the root group has depth 1.
+// MaxLimit and MaxSort restrict the page size and the number of sort fields of Query.
+type Limits struct {
+	MaxDepth    int
+	MaxRules    int
+	MaxIncludes int
+	MaxParams   int
+	MaxLimit    int
+	MaxSort     int
+}
+
+// DefaultLimits returns limits suitable for public-facing endpoints
+func DefaultLimits() *Limits {
+	return &Limits{
+		MaxDepth:    5,
+		MaxRules:    50,
+		MaxIncludes: 500,
+		MaxParams:   1000,
+		MaxLimit:    1000,
+		MaxSort:     5,
+	}
+}
+
+type limitCounter struct {
+	limits *Limits
+	rules  int
+	params int
+}
+
+func checkLimits(data Filter, config *SQLConfig) error {
+	if config == nil || config.Limits == nil {
+		return nil
+	}
+
+	c := limitCounter{limits: config.Limits}
+	return c.check(data, "", 0)
+}
+
+func (c *limitCounter) check(data Filter, path string, depth int) error {
+	l := c.limits
+	if data.trusted {
+		return nil
+	}
+
+	if data.Rules != nil {
+		depth++
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return limitError(path, "", "max depth is %d", l.MaxDepth)
+		}
+
+		for i, r := range data.Rules {
+			if r.trusted {
+				continue
+			}
+			if r.isolated {
+				// client part of Scope is checked as the root filter
+				if err := c.check(r, "", depth-1); err != nil {
+					return err
+				}
+				continue
+			}
+
+			c.rules++
+			if l.MaxRules > 0 && c.rules > l.MaxRules {
+				return limitError(childPath(path, i), r.Field, "max number of rules is %d", l.MaxRules)
+			}
+
+			if err := c.check(r, childPath(path, i), depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if l.MaxIncludes > 0 && len(data.Includes) > l.MaxIncludes {
+		return limitError(path, data.Field, "max number of included values is %d", l.MaxIncludes)
+	}
+
+	if len(data.Includes) > 0 {
+		c.params += len(data.Includes)
+	} else if data.Filter != "" {
+		c.params += len(data.getValues())
+	}
+	return checkParams(c.params, l, path)
+}
+
+func checkParams(count int, l *Limits, path string) error {
+	if l.MaxParams > 0 && count > l.MaxParams {
+		return limitError(path, "", "max number of parameters is %d", l.MaxParams)
+	}
+	return nil
+}
+
+func limitError(path string, field string, format string, args ...interface{}) error {
+	err := ruleError(ErrLimitExceeded, field, format, args...)
+	err.Path = path
+	return err
+}
@@ -0,0 +1,64 @@
+package querysql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	config := &SQLConfig{Limits: &Limits{MaxDepth: 2, MaxRules: 4, MaxIncludes: 3, MaxParams: 5}}
+
+	valid := []string{
+		aAndB,
+		`{ "glue":"and", "rules":[` + aAndB + `,{ "field":"c", "includes":[1,2,3] }]}`,
+	}
+	invalid := map[string]string{
+		`{ "glue":"and", "rules":[{ "glue":"and", "rules":[` + aAndB + `]}]}`:                                                        "rules[0].rules[0]",
+		`{ "glue":"and", "rules":[` + aAndB + `,` + aOrB + `]}`:                                                                      "rules[1].rules[0]",
+		`{ "glue":"and", "rules":[{ "field":"c", "includes":[1,2,3,4] }]}`:                                                           "rules[0]",
+		`{ "glue":"and", "rules":[` + aAndB + `,{ "field":"c", "includes":[1,2,3] }, { "field":"d", "filter":"equal", "value":1 }]}`: "rules[2]",
+	}
+
+	for _, text := range valid {
+		format, _ := FromJSON([]byte(text))
+		if _, _, err := GetSQL(format, config); err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+		}
+	}
+
+	for text, path := range invalid {
+		format, _ := FromJSON([]byte(text))
+		_, _, err := GetSQL(format, config)
+
+		var re *RuleError
+		if !errors.Is(err, ErrLimitExceeded) || !errors.As(err, &re) || re.Path != path {
+			t.Errorf("wrong error returned\nj: %s\ns: %s\nr: %v", text, path, err)
+		}
+
+		if errs := Validate(format, config); len(errs) != 1 || !errors.Is(errs[0], ErrLimitExceeded) {
+			t.Errorf("wrong validation result\nj: %s\nr: %v", text, errs)
+		}
+	}
+
+	// parameters added by custom operations are checked after rendering
+	format, _ := FromJSON([]byte(`{ "field":"c", "filter":"many", "value":1 }`))
+	_, _, err := GetSQL(format, &SQLConfig{
+		Limits: &Limits{MaxParams: 5},
+		Operations: map[string]CustomOperation{
+			"many": func(n string, r string, values []interface{}) (string, []interface{}, error) {
+				return fmt.Sprintf("%s IN(?,?,?,?,?,?)", n), []interface{}{1, 2, 3, 4, 5, 6}, nil
+			},
+		},
+	})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("doesn't return error for too many parameters\nr: %v", err)
+	}
+
+	deep := strings.Repeat(`{ "glue":"and", "rules":[`, 100) + strings.Repeat(`]}`, 100)
+	format, _ = FromJSON([]byte(deep))
+	if _, _, err := GetSQL(format, &SQLConfig{Limits: DefaultLimits()}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("doesn't return error for a deep filter\nr: %v", err)
+	}
+}
@@ -1,12 +1,18 @@
 package querysql
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 type MySQL struct{}
 
-func (m MySQL) Mark() string {
+func (m MySQL) Dialect() string {
+	return DialectMySQL
+}
+
+func (m MySQL) Mark(index int) string {
 	return "?"
 }
 
@@ -14,30 +20,73 @@ func (m MySQL) IsJSON(name string) (string, bool) {
 	return name, false
 }
 
-func (m MySQL) Contains(v string, isJSON bool) string {
-	return fmt.Sprintf("INSTR(%s, ?) > 0", v)
+func (m MySQL) Contains(v string, mark string, isJSON bool) string {
+	return fmt.Sprintf("INSTR(%s, %s) > 0", v, mark)
 }
 
-func (m MySQL) NotContains(v string, isJSON bool) string {
-	return fmt.Sprintf("INSTR(%s, ?) = 0", v)
+func (m MySQL) NotContains(v string, mark string, isJSON bool) string {
+	return fmt.Sprintf("INSTR(%s, %s) = 0", v, mark)
 }
 
-func (m MySQL) BeginsWith(v string, isJSON bool) string {
-	search := "CONCAT(?, '%')"
+func (m MySQL) BeginsWith(v string, mark string, isJSON bool) string {
+	search := fmt.Sprintf("CONCAT(%s, '%%')", mark)
 	return fmt.Sprintf("%s LIKE %s", v, search)
 }
 
-func (m MySQL) NotBeginsWith(v string, isJSON bool) string {
-	search := "CONCAT(?, '%')"
+func (m MySQL) NotBeginsWith(v string, mark string, isJSON bool) string {
+	search := fmt.Sprintf("CONCAT(%s, '%%')", mark)
 	return fmt.Sprintf("%s NOT LIKE %s", v, search)
 }
 
-func (m MySQL) EndsWith(v string, isJSON bool) string {
-	search := "CONCAT('%', ?)"
+func (m MySQL) EndsWith(v string, mark string, isJSON bool) string {
+	search := fmt.Sprintf("CONCAT('%%', %s)", mark)
 	return fmt.Sprintf("%s LIKE %s", v, search)
 }
 
-func (m MySQL) NotEndsWith(v string, isJSON bool) string {
-	search := "CONCAT('%', ?)"
+func (m MySQL) NotEndsWith(v string, mark string, isJSON bool) string {
+	search := fmt.Sprintf("CONCAT('%%', %s)", mark)
 	return fmt.Sprintf("%s NOT LIKE %s", v, search)
 }
+
+var mysqlEscape = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`)
+
+var mysqlLiteral = literalFormat{
+	quote: func(s string) string { return "'" + mysqlEscape.Replace(s) + "'" },
+	bytes: func(b []byte) string { return "X'" + hex.EncodeToString(b) + "'" },
+	time:  "2006-01-02 15:04:05.999999",
+}
+
+// OrderBy emulates NULLS FIRST and NULLS LAST, as MySQL sorts NULL as the smallest value
+func (m MySQL) OrderBy(v string, desc bool, nulls string) string {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+
+	switch nulls {
+	case "first":
+		return fmt.Sprintf("%s IS NULL DESC, %s %s", v, v, dir)
+	case "last":
+		return fmt.Sprintf("%s IS NULL, %s %s", v, v, dir)
+	}
+	return fmt.Sprintf("%s %s", v, dir)
+}
+
+// Limit uses the max value of LIMIT for OFFSET without LIMIT, which is not supported by MySQL
+func (m MySQL) Limit(limit, offset int) string {
+	if offset == 0 {
+		if limit == 0 {
+			return ""
+		}
+		return fmt.Sprintf("LIMIT %d", limit)
+	}
+
+	if limit == 0 {
+		return fmt.Sprintf("LIMIT 18446744073709551615 OFFSET %d", offset)
+	}
+	return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+}
+
+func (m MySQL) Literal(v interface{}) string {
+	return mysqlLiteral.format(v)
+}
@@ -0,0 +1,54 @@
+package querysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// NamedParams switches rendering to named parameters,
+// GetSQL returns values as sql.NamedArg in the order of placeholders
+type NamedParams struct {
+	// Prefix of the parameter in SQL, ":" (sqlx) when empty, "@" for pgx and SQL Server
+	Prefix string
+	// Name returns the name of the parameter with the given 1-based index, "p1", "p2", ... when nil
+	Name func(index int) string
+}
+
+func (n *NamedParams) name(index int) string {
+	if n.Name != nil {
+		return n.Name(index)
+	}
+	return "p" + strconv.Itoa(index)
+}
+
+func (n *NamedParams) mark(index int) string {
+	prefix := n.Prefix
+	if prefix == "" {
+		prefix = ":"
+	}
+	return prefix + n.name(index)
+}
+
+// namedValues converts values to sql.NamedArg, first is the index of the first value
+func (n *NamedParams) namedValues(values []interface{}, first int) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = sql.Named(n.name(first+i), v)
+	}
+	return out
+}
+
+// NamedValues converts values returned by GetSQL in the named mode to a map,
+// which can be used with sqlx.Named or pgx.NamedArgs
+func NamedValues(values []interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(values))
+	for _, v := range values {
+		arg, ok := v.(sql.NamedArg)
+		if !ok {
+			return nil, fmt.Errorf("named parameter expected, got %v", v)
+		}
+		out[arg.Name] = arg.Value
+	}
+	return out, nil
+}
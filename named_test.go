@@ -0,0 +1,67 @@
+package querysql
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNamedParams(t *testing.T) {
+	text := `{ "glue":"and", "rules":[{ "field": "a", "filter":"less", "value":1 }, { "field": "b", "filter":"contains", "value":"x" }, { "field": "c", "includes":[1,2] }]}`
+	format, err := FromJSON([]byte(text))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", text, err)
+		return
+	}
+
+	sqlText, vals, err := GetSQL(format, &SQLConfig{NamedParams: &NamedParams{}}, PostgreSQL{})
+	if err != nil {
+		t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+		return
+	}
+
+	check := "( a < :p1 AND b LIKE '%' || :p2 || '%' AND c IN(:p3,:p4) )"
+	if sqlText != check {
+		t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", text, check, sqlText)
+	}
+
+	named := []interface{}{sql.Named("p1", 1.0), sql.Named("p2", "x"), sql.Named("p3", 1.0), sql.Named("p4", 2.0)}
+	if !reflect.DeepEqual(vals, named) {
+		t.Errorf("wrong values generated\nj: %s\ns: %v\nr: %v", text, named, vals)
+	}
+
+	valuesMap, err := NamedValues(vals)
+	if err != nil || len(valuesMap) != 4 || valuesMap["p2"] != "x" {
+		t.Errorf("wrong map of values\nr: %v %v", valuesMap, err)
+	}
+
+	rc := NewRenderContext(&SQLConfig{NamedParams: &NamedParams{
+		Prefix: "@",
+		Name:   func(i int) string { return fmt.Sprintf("f_%d", i) },
+	}}, MySQL{})
+	rc.Start = 5
+	sqlText, vals, _ = rc.GetSQL(format)
+
+	check = "( a < @f_5 AND INSTR(b, @f_6) > 0 AND c IN(@f_7,@f_8) )"
+	if sqlText != check || vals[3].(sql.NamedArg).Name != "f_8" {
+		t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s %v", text, check, sqlText, vals)
+	}
+
+	// custom operations which don't use RenderContext can't be used with named parameters
+	format, _ = FromJSON([]byte(cOrC))
+	_, _, err = GetSQL(format, &SQLConfig{
+		NamedParams: &NamedParams{},
+		Operations: map[string]CustomOperation{
+			"is null": func(n string, r string, values []interface{}) (string, []interface{}, error) {
+				return n + " IS NULL", NoValues, nil
+			},
+			"range100": func(n string, r string, values []interface{}) (string, []interface{}, error) {
+				return n + " > ?", values, nil
+			},
+		},
+	})
+	if err == nil {
+		t.Errorf("doesn't return error for custom placeholders")
+	}
+}
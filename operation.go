@@ -0,0 +1,24 @@
+package querysql
+
+// OperationCall describes the rule rendered by a RuleOperation.
+// Field is the SQL expression of the field after aliases, JSON translation and predicates,
+// Values are the values of the rule, { "start", "end" } objects are converted to two values.
+// FieldValues are bound by the predicates of Field, they are added before the returned values.
+// With anonymous placeholders of MySQL, an operation which uses Field more than once
+// must return FieldValues again before the values of each following use.
+type OperationCall struct {
+	Rule        Filter
+	Field       string
+	IsJSON      bool
+	Values      []interface{}
+	FieldValues []interface{}
+}
+
+// RuleOperation is a custom operation with access to the render context,
+// placeholders must be allocated with rc.Mark() in the order of returned values
+//
+//	"range100": func(rc *querysql.RenderContext, op querysql.OperationCall) (string, []interface{}, error) {
+//		sql := fmt.Sprintf("( %s > %s AND %s < %s + 100 )", op.Field, rc.Mark(), op.Field, rc.Mark())
+//		return sql, []interface{}{op.Values[0], op.Values[0]}, nil
+//	},
+type RuleOperation func(rc *RenderContext, op OperationCall) (string, []interface{}, error)
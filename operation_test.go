@@ -0,0 +1,52 @@
+package querysql
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRuleOperation(t *testing.T) {
+	config := &SQLConfig{
+		Aliases: map[string]string{"b": "json:cfg.b"},
+		RuleOperations: map[string]RuleOperation{
+			"is null": func(rc *RenderContext, op OperationCall) (string, []interface{}, error) {
+				return fmt.Sprintf("%s IS NULL", op.Field), NoValues, nil
+			},
+			"range100": func(rc *RenderContext, op OperationCall) (string, []interface{}, error) {
+				if !op.IsJSON || op.Rule.Field != "b" {
+					return "", nil, fmt.Errorf("wrong operation call: %+v", op)
+				}
+				sql := fmt.Sprintf("( %s > %s AND %s < %s + 100 )", op.Field, rc.Mark(), op.Field, rc.Mark())
+				return sql, []interface{}{op.Values[0], op.Values[0]}, nil
+			},
+		},
+	}
+
+	text := `{ "glue":"and", "rules":[{ "field": "x", "filter":"equal", "value":1 }, ` + cOrC + `]}`
+	format, err := FromJSON([]byte(text))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", text, err)
+		return
+	}
+
+	checks := []struct {
+		config *SQLConfig
+		db     DBDriver
+		sql    string
+	}{
+		{config, PostgreSQL{}, "( x = $1 AND ( a IS NULL OR ( (\"cfg\"->'b')::text > $2 AND (\"cfg\"->'b')::text < $3 + 100 ) ) )"},
+		{&SQLConfig{Aliases: config.Aliases, RuleOperations: config.RuleOperations, NamedParams: &NamedParams{}}, PostgreSQL{},
+			"( x = :p1 AND ( a IS NULL OR ( (\"cfg\"->'b')::text > :p2 AND (\"cfg\"->'b')::text < :p3 + 100 ) ) )"},
+	}
+
+	for _, c := range checks {
+		sql, vals, err := GetSQL(format, c.config, c.db)
+		if err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+			continue
+		}
+		if sql != c.sql || len(vals) != 3 {
+			t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", text, c.sql, sql)
+		}
+	}
+}
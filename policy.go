@@ -0,0 +1,96 @@
+package querysql
+
+// Principal describes the user on whose behalf the filter is rendered
+type Principal struct {
+	User   string
+	Roles  []string
+	Claims map[string]interface{}
+}
+
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	return contains(p.Roles, role)
+}
+
+// PolicyResult contains restrictions of a policy for the current principal.
+// Scopes are trusted conditions combined with the filter by AND,
+// DenyFields and DenyOperations reject rules of the filter which use them.
+type PolicyResult struct {
+	Scopes         []Filter
+	DenyFields     []string
+	DenyOperations []string
+}
+
+// Policy returns restrictions for the principal, which is nil for anonymous requests
+type Policy func(p *Principal) (PolicyResult, error)
+
+// GetSQLFor renders the filter applying policies of the config for the principal
+func GetSQLFor(p *Principal, data Filter, config *SQLConfig, dbArr ...DBDriver) (string, []interface{}, error) {
+	var db DBDriver
+	if len(dbArr) > 0 {
+		db = dbArr[0]
+	}
+
+	rc := NewRenderContext(config, db)
+	rc.Principal = p
+	return rc.GetSQL(data)
+}
+
+// applyPolicies adds scopes of policies to the filter and stores denied fields and operations
+func (rc *RenderContext) applyPolicies(data Filter) (Filter, error) {
+	scopes, err := rc.loadPolicies()
+	if err != nil {
+		return data, err
+	}
+
+	if len(scopes) > 0 {
+		data = Scope(data, scopes...)
+	}
+	return data, nil
+}
+
+// loadPolicies stores denied fields and operations and returns the scopes of policies
+func (rc *RenderContext) loadPolicies() ([]Filter, error) {
+	if rc.Config == nil || len(rc.Config.Policies) == 0 {
+		return nil, nil
+	}
+
+	scopes := make([]Filter, 0)
+	rc.denyFields = make(map[string]bool)
+	rc.denyOperations = make(map[string]bool)
+
+	for _, policy := range rc.Config.Policies {
+		res, err := policy(rc.Principal)
+		if err != nil {
+			return nil, err
+		}
+
+		scopes = append(scopes, res.Scopes...)
+		for _, f := range res.DenyFields {
+			rc.denyFields[f] = true
+		}
+		for _, op := range res.DenyOperations {
+			rc.denyOperations[op] = true
+		}
+	}
+
+	return scopes, nil
+}
+
+func (rc *RenderContext) checkPolicies(data Filter) error {
+	if rc.denyFields[data.Field] {
+		return ruleError(ErrFieldNotAllowed, data.Field, "%s", data.Field)
+	}
+
+	op := data.Filter
+	if data.Includes != nil {
+		op = "includes"
+	}
+	if rc.denyOperations[op] {
+		return ruleError(ErrOperationNotAllowed, data.Field, "%s for field %s", op, data.Field)
+	}
+
+	return nil
+}
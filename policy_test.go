@@ -0,0 +1,88 @@
+package querysql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicies(t *testing.T) {
+	config := &SQLConfig{
+		Whitelist: map[string]bool{"a": true, "b": true, "salary": true},
+		Policies: []Policy{
+			func(p *Principal) (PolicyResult, error) {
+				if p.HasRole("manager") {
+					return PolicyResult{}, nil
+				}
+
+				res := PolicyResult{DenyFields: []string{"salary"}, DenyOperations: []string{"contains"}}
+				if p != nil {
+					res.Scopes = []Filter{Field("region").Equal(p.Claims["region"])}
+				}
+				return res, nil
+			},
+		},
+	}
+
+	format, _ := FromJSON([]byte(aOrB))
+	rep := &Principal{User: "rep", Roles: []string{"sales"}, Claims: map[string]interface{}{"region": 3}}
+
+	sql, vals, err := GetSQLFor(rep, format, config, PostgreSQL{})
+	if err != nil {
+		t.Errorf("can't generate sql\nj: %s\n%s", aOrB, err)
+		return
+	}
+	check := "( region = $1 AND ( a < $2 OR b > $3 ) )"
+	if sql != check || vals[0] != 3 {
+		t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", aOrB, check, sql)
+	}
+
+	sql, _, _ = GetSQLFor(&Principal{Roles: []string{"manager"}}, format, config, PostgreSQL{})
+	if sql != "( a < $1 OR b > $2 )" {
+		t.Errorf("wrong sql generated for manager\nj: %s\nr: %s", aOrB, sql)
+	}
+
+	for _, text := range []string{
+		`{ "field": "salary", "filter":"less", "value":1 }`,
+		`{ "field": "a", "filter":"less", "value":{ "field":"salary" } }`,
+		`{ "field": "b", "filter":"contains", "value":"x" }`,
+	} {
+		format, _ := FromJSON([]byte(text))
+		_, _, err := GetSQLFor(rep, format, config)
+		if !errors.Is(err, ErrFieldNotAllowed) && !errors.Is(err, ErrOperationNotAllowed) {
+			t.Errorf("doesn't deny the rule\nj: %s\nr: %v", text, err)
+		}
+
+		// anonymous requests get the same restrictions
+		if _, _, err := GetSQL(format, config); err == nil {
+			t.Errorf("doesn't deny the rule for anonymous request\nj: %s", text)
+		}
+	}
+
+	// scope without allowed values matches no rows instead of disappearing
+	regions := &SQLConfig{Whitelist: config.Whitelist, Policies: []Policy{func(p *Principal) (PolicyResult, error) {
+		return PolicyResult{Scopes: []Filter{Field("region").In()}}, nil
+	}}}
+	sql, _, err = GetSQLFor(rep, format, regions, PostgreSQL{})
+	if err != nil || sql != "( 1 = 0 AND ( a < $1 OR b > $2 ) )" {
+		t.Errorf("wrong sql generated for empty scope\nr: %s\n%v", sql, err)
+	}
+
+	// scopes of policies don't change paths of errors
+	text := `{ "glue":"or", "rules":[{ "field": "a", "filter":"less", "value":1 }, { "field": "c", "filter":"less", "value":1 }]}`
+	invalid, _ := FromJSON([]byte(text))
+	var re *RuleError
+	if _, _, err := GetSQLFor(rep, invalid, config); !errors.As(err, &re) || re.Path != "rules[1]" {
+		t.Errorf("wrong path of the error\nj: %s\nr: %v", text, err)
+	}
+	if errs := ValidateFor(rep, invalid, config); len(errs) != 1 || !errors.As(errs[0], &re) || re.Path != "rules[1]" {
+		t.Errorf("wrong path of the validation error\nj: %s\nr: %v", text, errs)
+	}
+
+	denied := errors.New("denied")
+	_, _, err = GetSQL(format, &SQLConfig{Policies: []Policy{func(p *Principal) (PolicyResult, error) {
+		return PolicyResult{}, denied
+	}}})
+	if !errors.Is(err, denied) {
+		t.Errorf("doesn't return error of the policy\nr: %v", err)
+	}
+}
@@ -1,25 +1,27 @@
 package querysql
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strings"
 )
 
-type PostgreSQL struct {
-	counter int
-}
+type PostgreSQL struct{}
+
+// Reset is kept for compatibility, numbering of placeholders is stored in RenderContext.
+//
+// Deprecated: PostgreSQL has no state, use RenderContext.Start to change the first index.
+func (m PostgreSQL) Reset() {}
 
-func (m *PostgreSQL) Reset() {
-	m.counter = 1
+func (m PostgreSQL) Dialect() string {
+	return DialectPostgreSQL
 }
 
-func (m *PostgreSQL) Mark() string {
-	m.counter += 1
-	t := fmt.Sprintf("$%d", m.counter)
-	return t
+func (m PostgreSQL) Mark(index int) string {
+	return fmt.Sprintf("$%d", index)
 }
 
-func (m *PostgreSQL) IsJSON(v string) (string, bool) {
+func (m PostgreSQL) IsJSON(v string) (string, bool) {
 	//table.json:field.name:type
 	fieldOnly := strings.HasPrefix(v, "json:")
 	var dot int
@@ -62,57 +64,90 @@ func (m *PostgreSQL) IsJSON(v string) (string, bool) {
 	return fmt.Sprintf("%s(\"%s\"->'%s')::%s%s", s, name[0], name[1], tp, e), true
 }
 
-func (m *PostgreSQL) Contains(v string, isJSON bool) string {
+func (m PostgreSQL) Contains(v string, mark string, isJSON bool) string {
 	if isJSON {
 		// Quotes (" ... ") are needed for correct work. Fields of type text in JSONB are wrapped by default
-		return fmt.Sprintf("%s LIKE '\"%%' || %s || '%%\"'", v, m.Mark())
+		return fmt.Sprintf("%s LIKE '\"%%' || %s || '%%\"'", v, mark)
 	}
-	return fmt.Sprintf("%s LIKE '%%' || %s || '%%'", v, m.Mark())
+	return fmt.Sprintf("%s LIKE '%%' || %s || '%%'", v, mark)
 }
 
-func (m *PostgreSQL) NotContains(v string, isJSON bool) string {
+func (m PostgreSQL) NotContains(v string, mark string, isJSON bool) string {
 	if isJSON {
-		return fmt.Sprintf("%s NOT LIKE '\"%%' || %s || '%%\"'", v, m.Mark())
+		return fmt.Sprintf("%s NOT LIKE '\"%%' || %s || '%%\"'", v, mark)
 	}
-	return fmt.Sprintf("%s NOT LIKE '%%' || %s || '%%'", v, m.Mark())
+	return fmt.Sprintf("%s NOT LIKE '%%' || %s || '%%'", v, mark)
 }
 
-func (m *PostgreSQL) BeginsWith(v string, isJSON bool) string {
+func (m PostgreSQL) BeginsWith(v string, mark string, isJSON bool) string {
 	var search string
 	if isJSON {
-		search = "'\"' || " + m.Mark() + " || '%'"
+		search = "'\"' || " + mark + " || '%'"
 	} else {
-		search = m.Mark() + " || '%'"
+		search = mark + " || '%'"
 	}
 	return fmt.Sprintf("%s LIKE %s", v, search)
 }
 
-func (m *PostgreSQL) NotBeginsWith(v string, isJSON bool) string {
+func (m PostgreSQL) NotBeginsWith(v string, mark string, isJSON bool) string {
 	var search string
 	if isJSON {
-		search = "'\"' || " + m.Mark() + " || '%'"
+		search = "'\"' || " + mark + " || '%'"
 	} else {
-		search = m.Mark() + " || '%'"
+		search = mark + " || '%'"
 	}
 	return fmt.Sprintf("%s NOT LIKE %s", v, search)
 }
 
-func (m *PostgreSQL) EndsWith(v string, isJSON bool) string {
+func (m PostgreSQL) EndsWith(v string, mark string, isJSON bool) string {
 	var search string
 	if isJSON {
-		search = "'%' || " + m.Mark() + " || '\"'"
+		search = "'%' || " + mark + " || '\"'"
 	} else {
-		search = "'%' || " + m.Mark()
+		search = "'%' || " + mark
 	}
 	return fmt.Sprintf("%s LIKE %s", v, search)
 }
 
-func (m *PostgreSQL) NotEndsWith(v string, isJSON bool) string {
+func (m PostgreSQL) NotEndsWith(v string, mark string, isJSON bool) string {
 	var search string
 	if isJSON {
-		search = "'%' || " + m.Mark() + " || '\"'"
+		search = "'%' || " + mark + " || '\"'"
 	} else {
-		search = "'%' || " + m.Mark()
+		search = "'%' || " + mark
 	}
 	return fmt.Sprintf("%s NOT LIKE %s", v, search)
 }
+
+var postgresLiteral = literalFormat{
+	quote: func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" },
+	bytes: func(b []byte) string { return "'\\x" + hex.EncodeToString(b) + "'::bytea" },
+	time:  "2006-01-02 15:04:05.999999-07:00",
+}
+
+func (m PostgreSQL) OrderBy(v string, desc bool, nulls string) string {
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+
+	if nulls != "" {
+		return fmt.Sprintf("%s %s NULLS %s", v, dir, strings.ToUpper(nulls))
+	}
+	return fmt.Sprintf("%s %s", v, dir)
+}
+
+func (m PostgreSQL) Limit(limit, offset int) string {
+	out := make([]string, 0, 2)
+	if limit > 0 {
+		out = append(out, fmt.Sprintf("LIMIT %d", limit))
+	}
+	if offset > 0 {
+		out = append(out, fmt.Sprintf("OFFSET %d", offset))
+	}
+	return strings.Join(out, " ")
+}
+
+func (m PostgreSQL) Literal(v interface{}) string {
+	return postgresLiteral.format(v)
+}
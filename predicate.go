@@ -0,0 +1,224 @@
+package querysql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PredicateCall describes the predicate applied by a RulePredicate.
+// Field is the SQL expression of the field after aliases, JSON translation
+// and the previous predicates of the chain, Args are the arguments of the predicate from the rule.
+type PredicateCall struct {
+	Name   string
+	Field  string
+	IsJSON bool
+	Args   []interface{}
+	Rule   Filter
+}
+
+// RulePredicate is a custom predicate with access to the render context,
+// rc.DB allows to generate dialect specific SQL. Placeholders of the returned values
+// must be allocated with rc.Mark(), the values are placed before the values of the operation.
+//
+//	"mod": func(rc *querysql.RenderContext, p querysql.PredicateCall) (string, []interface{}, error) {
+//		return fmt.Sprintf("MOD(%s, %s)", p.Field, rc.Mark()), p.Args[:1], nil
+//	},
+type RulePredicate func(rc *RenderContext, p PredicateCall) (string, []interface{}, error)
+
+// PredicateType describes the types of values which are accepted and returned by a predicate.
+// Empty Accepts allows any type, empty Output keeps the type of the field.
+type PredicateType struct {
+	Accepts []string
+	Output  string
+}
+
+// predicateChain splits the predicate of the rule, "lower|trim" applies lower and then trim
+func predicateChain(predicate string) []string {
+	if predicate == "" {
+		return nil
+	}
+	return strings.Split(predicate, "|")
+}
+
+// predicateSQL applies the predicates of the rule to the field,
+// empty predicate means the raw field, hooks of the active dialect are checked first.
+// A predicate without a hook is an error, even when the config has no predicates.
+func predicateSQL(name string, isJSON bool, data Filter, config *SQLConfig, rc *RenderContext) (string, []interface{}, error) {
+	if data.Predicate == "" {
+		return name, NoValues, nil
+	}
+	if config == nil {
+		return "", nil, ruleError(ErrUnknownPredicate, data.Field, "%s", data.Predicate)
+	}
+
+	d, _ := getDialect(config, rc.DB)
+	values := make([]interface{}, 0)
+	for i, predicate := range predicateChain(data.Predicate) {
+		var err error
+		var out []interface{}
+		args := data.predicateArgs(i)
+		call := PredicateCall{Name: predicate, Field: name, IsJSON: isJSON, Args: args, Rule: data}
+
+		if pr, prOk := d.Predicates[predicate]; prOk {
+			name, out, err = pr(rc, call)
+		} else if pr, prOk := config.RulePredicates[predicate]; prOk {
+			name, out, err = pr(rc, call)
+		} else if _, prOk := config.Predicates[predicate]; prOk && len(args) > 0 {
+			err = ruleError(ErrBadValue, data.Field, "predicate %s doesn't accept arguments", predicate)
+		} else if pr, prOk := config.Predicates[predicate]; prOk {
+			name, err = pr(name, predicate)
+		} else if _, prOk := config.PredicatesContext[predicate]; prOk && len(args) > 0 {
+			err = ruleError(ErrBadValue, data.Field, "predicate %s doesn't accept arguments", predicate)
+		} else if pr, prOk := config.PredicatesContext[predicate]; prOk {
+			name, err = pr(rc.Ctx, name, predicate)
+		} else {
+			return "", nil, ruleError(ErrUnknownPredicate, data.Field, "%s", predicate)
+		}
+
+		if err != nil {
+			return "", nil, err
+		}
+		values = append(values, out...)
+	}
+
+	return name, values, nil
+}
+
+// withFieldValues places the values of predicates before the values of the operation.
+// Anonymous placeholders of MySQL require the values to be repeated for every use of the field,
+// built-in operations use the field twice only for between with both ends.
+func withFieldValues(data Filter, fieldValues, values, opValues []interface{}, rc *RenderContext) []interface{} {
+	out := append(make([]interface{}, 0, len(fieldValues)+len(opValues)), fieldValues...)
+	twice := (data.Filter == "between" || data.Filter == "notBetween") && len(values) == 2 && values[0] != nil && values[1] != nil
+	if len(fieldValues) == 0 || !twice || !rc.anonymousMarks() {
+		return append(out, opValues...)
+	}
+
+	first := 1
+	if _, isRef := values[0].(fieldRef); isRef {
+		first = 0
+	}
+	out = append(out, opValues[:first]...)
+	out = append(out, fieldValues...)
+	return append(out, opValues[first:]...)
+}
+
+// predicateOutput returns the type of the field after the predicates of the rule,
+// empty string means that the type is unknown and values are not coerced
+func predicateOutput(fs FieldSchema, data Filter, config *SQLConfig) (string, error) {
+	tp := fs.Type
+	for _, predicate := range predicateChain(data.Predicate) {
+		pt, ok := config.PredicateTypes[predicate]
+		if !ok {
+			tp = ""
+			continue
+		}
+
+		if tp != "" && pt.Accepts != nil && !contains(pt.Accepts, tp) {
+			return "", ruleError(ErrPredicateNotAllowed, data.Field, "%s doesn't accept %s values of field %s", predicate, tp, data.Field)
+		}
+		if pt.Output != "" {
+			tp = pt.Output
+		}
+	}
+
+	return tp, nil
+}
+
+// predicateRef is the object form of a predicate in JSON, { "name": "trunc", "args": ["week"] }
+type predicateRef struct {
+	Name string        `json:"name"`
+	Args []interface{} `json:"args,omitempty"`
+}
+
+func (f *Filter) predicateArgs(i int) []interface{} {
+	if i < len(f.PredicateArgs) {
+		return f.PredicateArgs[i]
+	}
+	return nil
+}
+
+// UnmarshalJSON accepts the predicate as a string, an object with arguments
+// or an array of strings and objects, which is a chain of predicates
+func (f *Filter) UnmarshalJSON(text []byte) error {
+	type plain Filter
+	aux := struct {
+		*plain
+		Predicate json.RawMessage `json:"predicate"`
+	}{plain: (*plain)(f)}
+
+	if err := json.Unmarshal(text, &aux); err != nil {
+		return err
+	}
+	return f.parsePredicate(aux.Predicate)
+}
+
+func (f *Filter) parsePredicate(text json.RawMessage) error {
+	f.Predicate = ""
+	f.PredicateArgs = nil
+	if len(text) == 0 || string(text) == "null" {
+		return nil
+	}
+
+	var refs []json.RawMessage
+	if text[0] == '[' {
+		if err := json.Unmarshal(text, &refs); err != nil {
+			return err
+		}
+	} else {
+		refs = []json.RawMessage{text}
+	}
+
+	names := make([]string, len(refs))
+	for i, raw := range refs {
+		var ref predicateRef
+		if len(raw) > 0 && raw[0] == '"' {
+			if err := json.Unmarshal(raw, &ref.Name); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(raw, &ref); err != nil {
+			return fmt.Errorf("wrong predicate: %s", raw)
+		}
+
+		// only the string form can be a chain, otherwise arguments would belong to the wrong predicate
+		if strings.Contains(ref.Name, "|") && (len(refs) > 1 || raw[0] != '"') {
+			return fmt.Errorf("wrong predicate: %s", raw)
+		}
+
+		names[i] = ref.Name
+		if len(ref.Args) > 0 {
+			for len(f.PredicateArgs) < i {
+				f.PredicateArgs = append(f.PredicateArgs, nil)
+			}
+			f.PredicateArgs = append(f.PredicateArgs, ref.Args)
+		}
+	}
+
+	f.Predicate = strings.Join(names, "|")
+	return nil
+}
+
+// MarshalJSON writes predicates with arguments in the object form
+func (f Filter) MarshalJSON() ([]byte, error) {
+	type plain Filter
+	if len(f.PredicateArgs) == 0 {
+		return json.Marshal(plain(f))
+	}
+
+	chain := predicateChain(f.Predicate)
+	refs := make([]predicateRef, len(chain))
+	for i, name := range chain {
+		refs[i] = predicateRef{Name: name, Args: f.predicateArgs(i)}
+	}
+
+	var predicate interface{} = refs
+	if len(refs) == 1 {
+		predicate = refs[0]
+	}
+
+	return json.Marshal(struct {
+		plain
+		Predicate interface{} `json:"predicate"`
+	}{plain(f), predicate})
+}
@@ -0,0 +1,186 @@
+package querysql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPredicateChain(t *testing.T) {
+	config := &SQLConfig{
+		Predicates: map[string]CustomPredicate{
+			"lower": func(n string, p string) (string, error) { return fmt.Sprintf("LOWER(%s)", n), nil },
+			"trim":  func(n string, p string) (string, error) { return fmt.Sprintf("TRIM(%s)", n), nil },
+		},
+	}
+
+	checks := [][]string{
+		{`{ "field": "a", "filter":"equal", "value":1 }`, "a = ?"},
+		{`{ "field": "a", "predicate":"lower", "filter":"equal", "value":"x" }`, "LOWER(a) = ?"},
+		{`{ "field": "a", "predicate":"lower|trim", "filter":"equal", "value":"x" }`, "TRIM(LOWER(a)) = ?"},
+	}
+
+	for _, c := range checks {
+		format, err := FromJSON([]byte(c[0]))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", c[0], err)
+			continue
+		}
+
+		sql, _, err := GetSQL(format, config)
+		if err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", c[0], err)
+			continue
+		}
+		if sql != c[1] {
+			t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", c[0], c[1], sql)
+		}
+	}
+
+	format, _ := FromJSON([]byte(`{ "field": "a", "predicate":"lower|upper", "filter":"equal", "value":"x" }`))
+	if _, _, err := GetSQL(format, config); !errors.Is(err, ErrUnknownPredicate) {
+		t.Errorf("unknown predicate of the chain is accepted: %v", err)
+	}
+
+	// predicates are not ignored when the config has no hooks
+	format, _ = FromJSON([]byte(`{ "field": "a", "predicate":"lower", "filter":"equal", "value":"x" }`))
+	for _, c := range []*SQLConfig{nil, {Whitelist: map[string]bool{"a": true}}} {
+		if _, _, err := GetSQL(format, c); !errors.Is(err, ErrUnknownPredicate) {
+			t.Errorf("predicate without hooks is accepted: %v", err)
+		}
+	}
+}
+
+func TestPredicateTypes(t *testing.T) {
+	config := &SQLConfig{
+		Schema: map[string]FieldSchema{
+			"d":    {Type: TypeDate},
+			"name": {Type: TypeText, Predicates: []string{"lower", "trim"}},
+		},
+	}
+	AddStandardPredicates(config)
+
+	format, _ := FromJSON([]byte(`{ "field": "d", "predicate":"year", "filter":"equal", "value":"2024" }`))
+	_, values, err := GetSQL(format, config)
+	if err != nil {
+		t.Errorf("can't generate sql\n%s", err)
+	} else if values[0] != float64(2024) {
+		t.Errorf("value is not converted to the output type of predicate: %#v", values[0])
+	}
+
+	format, _ = FromJSON([]byte(`{ "field": "d", "predicate":"year", "includes":["2020", 2021] }`))
+	sql, values, err := GetSQL(format, config)
+	if err != nil || sql != "YEAR(d) IN(?,?)" {
+		t.Errorf("predicate is not applied to includes\nr: %s %v", sql, err)
+	} else if fmt.Sprint(values) != "[2020 2021]" || values[0] != float64(2020) {
+		t.Errorf("wrong values of includes: %#v", values)
+	}
+
+	errs := []struct {
+		json string
+		err  error
+	}{
+		{`{ "field": "d", "predicate":"year", "filter":"equal", "value":"x" }`, ErrBadValue},
+		{`{ "field": "d", "predicate":"lower", "filter":"equal", "value":"x" }`, ErrPredicateNotAllowed},
+		{`{ "field": "d", "predicate":"year|lower", "filter":"equal", "value":"x" }`, ErrPredicateNotAllowed},
+		{`{ "field": "name", "predicate":"lower|length", "filter":"equal", "value":1 }`, ErrPredicateNotAllowed},
+	}
+
+	for _, c := range errs {
+		format, _ := FromJSON([]byte(c.json))
+		if _, _, err := GetSQL(format, config); !errors.Is(err, c.err) {
+			t.Errorf("wrong error\nj: %s\ns: %v\nr: %v", c.json, c.err, err)
+		}
+	}
+
+	format, _ = FromJSON([]byte(`{ "field": "name", "predicate":"trim|lower", "filter":"equal", "value":"x" }`))
+	if sql, _, err := GetSQL(format, config, PostgreSQL{}); err != nil || sql != "LOWER(TRIM(name)) = $1" {
+		t.Errorf("wrong sql generated\nr: %s %v", sql, err)
+	}
+}
+
+func TestPredicateArgs(t *testing.T) {
+	config := &SQLConfig{
+		Predicates: map[string]CustomPredicate{
+			"legacy": func(n string, p string) (string, error) { return n, nil },
+		},
+	}
+	AddStandardPredicates(config)
+
+	checks := []struct {
+		json   string
+		db     DBDriver
+		sql    string
+		values []interface{}
+	}{
+		{`{ "field": "d", "predicate":{ "name":"trunc", "args":["week"] }, "filter":"equal", "value":"2024-01-01" }`, PostgreSQL{},
+			"DATE_TRUNC('week', d) = $1", []interface{}{"2024-01-01"}},
+		{`{ "field": "n", "predicate":{ "name":"mod", "args":[3] }, "filter":"equal", "value":1 }`, PostgreSQL{},
+			"MOD(n, $1) = $2", []interface{}{3.0, 1.0}},
+		{`{ "field": "n", "predicate":{ "name":"mod", "args":[3] }, "filter":"between", "value":{ "start":0, "end":2 } }`, PostgreSQL{},
+			"( MOD(n, $1) > $2 AND MOD(n, $1) < $3 )", []interface{}{3.0, 0.0, 2.0}},
+		{`{ "field": "n", "predicate":{ "name":"mod", "args":[3] }, "filter":"between", "value":{ "start":0, "end":2 } }`, MySQL{},
+			"( MOD(n, ?) > ? AND MOD(n, ?) < ? )", []interface{}{3.0, 0.0, 3.0, 2.0}},
+		{`{ "field": "n", "predicate":{ "name":"mod", "args":[3] }, "includes":[0, 1] }`, PostgreSQL{},
+			"MOD(n, $1) IN($2,$3)", []interface{}{3.0, 0.0, 1.0}},
+		{`{ "field": "s", "predicate":["trim", { "name":"coalesce", "args":["-"] }], "filter":"equal", "value":"x" }`, MySQL{},
+			"COALESCE(TRIM(s), ?) = ?", []interface{}{"-", "x"}},
+		// rule without operation doesn't allocate placeholders for arguments
+		{`{ "glue":"and", "rules":[{ "field": "a", "predicate":{ "name":"mod", "args":[3] }, "filter":"" }, { "field": "b", "filter":"equal", "value":1 }]}`, PostgreSQL{},
+			"b = $1", []interface{}{1.0}},
+	}
+
+	for _, c := range checks {
+		format, err := FromJSON([]byte(c.json))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", c.json, err)
+			continue
+		}
+
+		sql, values, err := GetSQL(format, config, c.db)
+		if err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", c.json, err)
+			continue
+		}
+		if sql != c.sql {
+			t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", c.json, c.sql, sql)
+		}
+		if fmt.Sprint(values) != fmt.Sprint(c.values) {
+			t.Errorf("wrong values generated\nj: %s\ns: %v\nr: %v", c.json, c.values, values)
+		}
+
+		// arguments are kept when the filter is saved
+		text, _ := json.Marshal(format)
+		saved, _ := FromJSON(text)
+		if savedSQL, _, _ := GetSQL(saved, config, c.db); savedSQL != c.sql {
+			t.Errorf("predicate arguments are lost\nj: %s\nr: %s", c.json, text)
+		}
+	}
+
+	built := Field("s").Predicate("trim").PredicateWith("coalesce", "-").Equal("x")
+	if sql, _, err := GetSQL(built, config); err != nil || sql != "COALESCE(TRIM(s), ?) = ?" {
+		t.Errorf("wrong sql generated by builder\nr: %s %v", sql, err)
+	}
+
+	errs := []string{
+		`{ "field": "d", "predicate":{ "name":"trunc", "args":["century"] }, "filter":"equal", "value":1 }`,
+		`{ "field": "d", "predicate":{ "name":"year", "args":[1] }, "filter":"equal", "value":1 }`,
+		`{ "field": "d", "predicate":{ "name":"legacy", "args":[1] }, "filter":"equal", "value":1 }`,
+	}
+	for _, text := range errs {
+		format, _ := FromJSON([]byte(text))
+		if _, _, err := GetSQL(format, config); !errors.Is(err, ErrBadValue) {
+			t.Errorf("wrong predicate arguments are accepted\nj: %s\nr: %v", text, err)
+		}
+	}
+
+	for _, text := range []string{
+		`{ "field": "n", "predicate":{ "name":"lower|mod", "args":[3] }, "filter":"equal", "value":1 }`,
+		`{ "field": "n", "predicate":["lower|trim", { "name":"mod", "args":[3] }], "filter":"equal", "value":1 }`,
+	} {
+		if _, err := FromJSON([]byte(text)); err == nil {
+			t.Errorf("chain in the object form is accepted\nj: %s", text)
+		}
+	}
+}
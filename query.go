@@ -0,0 +1,200 @@
+package querysql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Query is a request of a data grid, it combines the filter with sorting and pagination.
+// Cursor is the token returned by NextCursor, it selects the rows after the last row of the previous page.
+type Query struct {
+	Filter Filter `json:"filter"`
+	Sort   []Sort `json:"sort"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Cursor string `json:"cursor"`
+}
+
+// Sort describes a single sort field, Dir is "asc" or "desc",
+// Nulls is "first", "last" or empty for the default order of the database
+type Sort struct {
+	Field string `json:"field"`
+	Dir   string `json:"dir"`
+	Nulls string `json:"nulls"`
+}
+
+// Paginator is an optional interface of DBDriver which renders sorting and pagination.
+// OrderBy returns the sort expression, nulls is "first", "last" or empty for the default order,
+// Limit returns LIMIT and OFFSET clauses, zero values are omitted.
+// GetQuery returns an error when the query has sorting or pagination and the driver doesn't implement it.
+type Paginator interface {
+	OrderBy(v string, desc bool, nulls string) string
+	Limit(limit, offset int) string
+}
+
+func getPaginator(db DBDriver) (Paginator, error) {
+	if p, ok := db.(Paginator); ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("database driver doesn't support sorting and pagination: %T", db)
+}
+
+// QuerySQL contains the clauses of the query, each of them can be empty
+type QuerySQL struct {
+	Where   string
+	OrderBy string
+	Limit   string
+	Values  []interface{}
+}
+
+// String joins the clauses, the result can be appended to SELECT
+func (q QuerySQL) String() string {
+	out := make([]string, 0, 3)
+	if q.Where != "" {
+		out = append(out, "WHERE "+q.Where)
+	}
+	if q.OrderBy != "" {
+		out = append(out, "ORDER BY "+q.OrderBy)
+	}
+	if q.Limit != "" {
+		out = append(out, q.Limit)
+	}
+	return strings.Join(out, " ")
+}
+
+func QueryFromJSON(text []byte) (Query, error) {
+	q := Query{}
+	err := json.Unmarshal(text, &q)
+
+	return q, err
+}
+
+// GetQuery renders the filter, sorting and pagination of the query,
+// sort fields are checked by the same whitelist and aliases as the fields of the filter
+func GetQuery(q Query, config *SQLConfig, dbArr ...DBDriver) (QuerySQL, error) {
+	var db DBDriver
+	if len(dbArr) > 0 {
+		db = dbArr[0]
+	}
+
+	return NewRenderContext(config, db).GetQuery(q)
+}
+
+func (rc *RenderContext) GetQuery(q Query) (QuerySQL, error) {
+	where, values, err := rc.GetSQL(q.Filter)
+	if err != nil {
+		return QuerySQL{}, err
+	}
+
+	names, err := rc.sortFields(q.Sort)
+	if err != nil {
+		return QuerySQL{}, err
+	}
+
+	if q.Cursor != "" {
+		where, values, err = rc.keyset(q, names, where, values)
+		if err != nil {
+			return QuerySQL{}, err
+		}
+	}
+
+	orderBy := make([]string, len(names))
+	if len(names) > 0 {
+		p, err := getPaginator(rc.DB)
+		if err != nil {
+			return QuerySQL{}, err
+		}
+		for i, s := range q.Sort {
+			orderBy[i] = p.OrderBy(names[i], s.Dir == "desc", s.Nulls)
+		}
+	}
+
+	limit, err := rc.limit(q.Limit, q.Offset)
+	if err != nil {
+		return QuerySQL{}, err
+	}
+
+	return QuerySQL{Where: where, OrderBy: strings.Join(orderBy, ", "), Limit: limit, Values: values}, nil
+}
+
+// keyset adds the condition of the cursor to the filter
+func (rc *RenderContext) keyset(q Query, names []string, where string, values []interface{}) (string, []interface{}, error) {
+	if len(q.Sort) == 0 || q.Offset != 0 {
+		return "", nil, cursorError("cursor requires sort fields and can't be used with offset")
+	}
+	for _, s := range q.Sort {
+		if s.Nulls != "" {
+			return "", nil, cursorError("cursor can't be used with the order of nulls")
+		}
+	}
+
+	cursor, err := decodeCursor(q.Cursor, q.Sort, rc.Config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	first := rc.index()
+	sql, cursorValues := rc.keysetSQL(names, q.Sort, cursor)
+	if rc.Config != nil && rc.Config.NamedParams != nil && !rc.inline {
+		cursorValues = rc.Config.NamedParams.namedValues(cursorValues, first)
+	}
+
+	if where != "" {
+		sql = "( " + where + " ) AND " + sql
+	}
+	return sql, append(values, cursorValues...), nil
+}
+
+// sortFields checks the sort fields and returns their SQL expressions
+func (rc *RenderContext) sortFields(sort []Sort) ([]string, error) {
+	config := rc.Config
+	if config != nil && config.Limits != nil && config.Limits.MaxSort > 0 && len(sort) > config.Limits.MaxSort {
+		return nil, limitError("sort", "", "max number of sort fields is %d", config.Limits.MaxSort)
+	}
+
+	names := make([]string, len(sort))
+	for i, s := range sort {
+		path := fmt.Sprintf("sort[%d]", i)
+		if s.Field == "" || !checkWhitelist(rc.Ctx, s.Field, config) || rc.denyFields[s.Field] {
+			return nil, &RuleError{Path: path, Field: s.Field, Err: ErrFieldNotAllowed, Detail: s.Field}
+		}
+		if s.Dir != "" && s.Dir != "asc" && s.Dir != "desc" {
+			return nil, &RuleError{Path: path, Field: s.Field, Err: ErrBadValue, Detail: "sort direction " + s.Dir}
+		}
+		if s.Nulls != "" && s.Nulls != "first" && s.Nulls != "last" {
+			return nil, &RuleError{Path: path, Field: s.Field, Err: ErrBadValue, Detail: "sort order of nulls " + s.Nulls}
+		}
+
+		names[i], _ = resolveField(s.Field, config, rc.DB)
+	}
+
+	return names, nil
+}
+
+// limit applies Limits.MaxLimit, which is also used when the query has no limit
+func (rc *RenderContext) limit(limit, offset int) (string, error) {
+	if limit < 0 || offset < 0 {
+		return "", &RuleError{Path: "limit", Err: ErrBadValue, Detail: fmt.Sprintf("negative limit or offset: %d, %d", limit, offset)}
+	}
+
+	if rc.Config != nil && rc.Config.Limits != nil && rc.Config.Limits.MaxLimit > 0 {
+		max := rc.Config.Limits.MaxLimit
+		if limit > max {
+			return "", limitError("limit", "", "max page size is %d", max)
+		}
+		if limit == 0 {
+			limit = max
+		}
+	}
+
+	if limit == 0 && offset == 0 {
+		return "", nil
+	}
+
+	p, err := getPaginator(rc.DB)
+	if err != nil {
+		return "", err
+	}
+	return p.Limit(limit, offset), nil
+}
@@ -0,0 +1,98 @@
+package querysql
+
+import (
+	"errors"
+	"testing"
+)
+
+// customDriver hides optional interfaces of the wrapped driver
+type customDriver struct {
+	DBDriver
+}
+
+// pageDriver is a custom driver which supports sorting and pagination
+type pageDriver struct {
+	customDriver
+}
+
+func (d pageDriver) OrderBy(v string, desc bool, nulls string) string {
+	return PostgreSQL{}.OrderBy(v, desc, nulls)
+}
+
+func (d pageDriver) Limit(limit, offset int) string {
+	return PostgreSQL{}.Limit(limit, offset)
+}
+
+func TestQuery(t *testing.T) {
+	config := &SQLConfig{
+		Whitelist: map[string]bool{"a": true, "b": true, "name": true},
+		Aliases:   map[string]string{"name": "c.full_name"},
+	}
+
+	text := `{ "filter": { "field": "a", "filter":"equal", "value":1 }, "sort":[{ "field":"name" }, { "field":"b", "dir":"desc", "nulls":"last" }], "limit":20, "offset":40 }`
+	q, err := QueryFromJSON([]byte(text))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", text, err)
+		return
+	}
+
+	checks := []struct {
+		db  DBDriver
+		sql string
+	}{
+		{MySQL{}, "WHERE a = ? ORDER BY c.full_name ASC, b IS NULL, b DESC LIMIT 20 OFFSET 40"},
+		{PostgreSQL{}, "WHERE a = $1 ORDER BY c.full_name ASC, b DESC NULLS LAST LIMIT 20 OFFSET 40"},
+		{pageDriver{customDriver{MySQL{}}}, "WHERE a = ? ORDER BY c.full_name ASC, b DESC NULLS LAST LIMIT 20 OFFSET 40"},
+	}
+
+	for _, c := range checks {
+		out, err := GetQuery(q, config, c.db)
+		if err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+			continue
+		}
+		if out.String() != c.sql || len(out.Values) != 1 {
+			t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", text, c.sql, out.String())
+		}
+	}
+
+	// drivers without Paginator can render only the filter
+	if _, err := GetQuery(q, config, customDriver{MySQL{}}); err == nil {
+		t.Errorf("sorting is rendered by driver without Paginator")
+	}
+	if _, err := GetQuery(Query{Limit: 10}, config, customDriver{MySQL{}}); err == nil {
+		t.Errorf("limit is rendered by driver without Paginator")
+	}
+	out, err := GetQuery(Query{Filter: q.Filter}, config, customDriver{MySQL{}})
+	if err != nil || out.String() != "WHERE a = ?" {
+		t.Errorf("wrong sql generated by driver without Paginator\nr: %s %v", out.String(), err)
+	}
+
+	out, _ = GetQuery(Query{Offset: 10}, config, MySQL{})
+	if out.String() != "LIMIT 18446744073709551615 OFFSET 10" {
+		t.Errorf("wrong offset without limit\nr: %s", out.String())
+	}
+
+	limited := &SQLConfig{Whitelist: config.Whitelist, Limits: DefaultLimits()}
+	out, _ = GetQuery(Query{}, limited, PostgreSQL{})
+	if out.String() != "LIMIT 1000" {
+		t.Errorf("max page size is not applied\nr: %s", out.String())
+	}
+
+	errs := []struct {
+		query Query
+		err   error
+	}{
+		{Query{Sort: []Sort{{Field: "x"}}}, ErrFieldNotAllowed},
+		{Query{Sort: []Sort{{Field: "a", Dir: "up"}}}, ErrBadValue},
+		{Query{Sort: []Sort{{Field: "a", Nulls: "middle"}}}, ErrBadValue},
+		{Query{Limit: -1}, ErrBadValue},
+		{Query{Limit: 1001}, ErrLimitExceeded},
+	}
+
+	for _, c := range errs {
+		if _, err := GetQuery(c.query, limited); !errors.Is(err, c.err) {
+			t.Errorf("wrong error\nq: %+v\ns: %v\nr: %v", c.query, c.err, err)
+		}
+	}
+}
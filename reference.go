@@ -0,0 +1,93 @@
+package querysql
+
+import (
+	"fmt"
+)
+
+// fieldRef is a value which points to another column, { "field": "ordered_at" } in JSON
+type fieldRef struct {
+	name string
+}
+
+var comparisons = map[string]string{
+	"equal":          "=",
+	"notEqual":       "<>",
+	"less":           "<",
+	"lessOrEqual":    "<=",
+	"greater":        ">",
+	"greaterOrEqual": ">=",
+}
+
+func fieldReference(v interface{}) (string, bool) {
+	valueMap, ok := v.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+
+	name, ok := valueMap["field"].(string)
+	return name, ok
+}
+
+// resolveReferences replaces field references with the column names,
+// referenced fields are checked against the same whitelist as the rule field
+func resolveReferences(data Filter, values []interface{}, config *SQLConfig, rc *RenderContext) ([]interface{}, bool, error) {
+	hasRefs := false
+	for i, v := range values {
+		name, ok := fieldReference(v)
+		if !ok {
+			continue
+		}
+
+		if !data.trusted && (!checkWhitelist(rc.Ctx, name, config) || rc.denyFields[name]) {
+			return nil, false, ruleError(ErrFieldNotAllowed, data.Field, "%s", name)
+		}
+
+		column, _ := resolveField(name, config, rc.DB)
+		values[i] = fieldRef{column}
+		hasRefs = true
+	}
+
+	return values, hasRefs, nil
+}
+
+func operand(v interface{}, rc *RenderContext) (string, []interface{}) {
+	if ref, ok := v.(fieldRef); ok {
+		return ref.name, NoValues
+	}
+
+	return rc.Mark(), []interface{}{v}
+}
+
+func referenceSQL(name string, data Filter, values []interface{}, rc *RenderContext) (string, []interface{}, error) {
+	filter := data.Filter
+	if op, ok := comparisons[filter]; ok {
+		right, out := operand(values[0], rc)
+		return fmt.Sprintf("%s %s %s", name, op, right), out, nil
+	}
+
+	var lo, hi, glue string
+	switch filter {
+	case "between":
+		lo, hi, glue = ">", "<", "AND"
+	case "notBetween":
+		lo, hi, glue = "<", ">", "OR"
+	default:
+		return "", nil, ruleError(ErrBadValue, data.Field, "operation doesn't support field references: %s", filter)
+	}
+
+	if len(values) != 2 {
+		return "", nil, ruleError(ErrBadValue, data.Field, "wrong number of parameters for %s operation: %d", filter, len(values))
+	}
+
+	if values[0] == nil {
+		right, out := operand(values[1], rc)
+		return fmt.Sprintf("%s %s %s", name, hi, right), out, nil
+	} else if values[1] == nil {
+		right, out := operand(values[0], rc)
+		return fmt.Sprintf("%s %s %s", name, lo, right), out, nil
+	}
+
+	start, out := operand(values[0], rc)
+	end, endValues := operand(values[1], rc)
+	return fmt.Sprintf("( %s %s %s %s %s %s %s )", name, lo, start, glue, name, hi, end), append(out, endValues...), nil
+}
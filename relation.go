@@ -0,0 +1,122 @@
+package querysql
+
+import (
+	"fmt"
+)
+
+// Relation describes a child table which can be filtered through EXISTS subqueries.
+// Table may contain an alias ("order_items i"), Join is the condition which links
+// child rows to the main table ("i.order_id = o.id"). Config is applied to the
+// nested rules instead of the parent config, so the child table has its own whitelist.
+// Config must restrict fields by a whitelist or a schema, otherwise client rules
+// which target the relation are rejected.
+type Relation struct {
+	Table  string
+	Join   string
+	Config *SQLConfig
+}
+
+func getRelation(name string, config *SQLConfig) (Relation, bool) {
+	if config == nil || config.Relations == nil {
+		return Relation{}, false
+	}
+
+	rel, ok := config.Relations[name]
+	return rel, ok
+}
+
+// checkRelation rejects client rules for relations without whitelist,
+// as any field name would be inlined into the subquery
+func checkRelation(data Filter, rel Relation) error {
+	if data.trusted || hasWhitelist(rel.Config) {
+		return nil
+	}
+	return ruleError(ErrFieldNotAllowed, data.Field, "relation %s has no whitelist", data.Relation)
+}
+
+func relationSQL(data Filter, config *SQLConfig, rc *RenderContext, path string) (string, []interface{}, error) {
+	rel, ok := getRelation(data.Relation, config)
+	if !ok {
+		return "", nil, &RuleError{Path: path, Field: data.Field, Err: ErrUnknownRelation, Detail: data.Relation}
+	}
+	if err := checkRelation(data, rel); err != nil {
+		return "", nil, withPath(err, path, data.Field)
+	}
+
+	if data.Aggregate != "" {
+		sql, values, err := aggregateSQL(data, rel, config, rc)
+		if err != nil {
+			return "", nil, withPath(err, path, data.Field)
+		}
+		return sql, values, nil
+	}
+
+	// single rule which targets the relation keeps the path of the rule
+	nested := data
+	nested.Relation = ""
+	nested.isolated = false
+	if data.Rules == nil && data.Field == "" {
+		nested.Rules = []Filter{}
+	}
+
+	sub, values, err := getSQL(nested, rel.Config, rc, path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM %s%s)", rel.Table, relationWhere(rel, sub)), values, nil
+}
+
+func relationWhere(rel Relation, sub string) string {
+	where := rel.Join
+	if where != "" && sub != "" {
+		where += " AND " + sub
+	} else if sub != "" {
+		where = sub
+	}
+
+	if where == "" {
+		return ""
+	}
+	return " WHERE " + where
+}
+
+var aggregates = map[string]string{
+	"count": "COUNT",
+	"sum":   "SUM",
+	"avg":   "AVG",
+	"min":   "MIN",
+	"max":   "MAX",
+}
+
+// aggregateSQL renders a correlated subquery over the relation and compares
+// its result using the regular operations of the parent config
+func aggregateSQL(data Filter, rel Relation, config *SQLConfig, rc *RenderContext) (string, []interface{}, error) {
+	fn, ok := aggregates[data.Aggregate]
+	if !ok {
+		return "", nil, ruleError(ErrUnknownOperation, data.Field, "aggregate %s", data.Aggregate)
+	}
+
+	if data.Rules != nil {
+		return "", nil, ruleError(ErrBadRule, data.Field, "aggregate rules can't contain nested rules: %s", data.Relation)
+	}
+
+	arg := "*"
+	if data.Field != "" {
+		if !data.trusted && !checkWhitelist(rc.Ctx, data.Field, rel.Config) {
+			return "", nil, ruleError(ErrFieldNotAllowed, data.Field, "%s", data.Field)
+		}
+		arg, _ = resolveField(data.Field, rel.Config, rc.DB)
+	} else if data.Aggregate != "count" {
+		return "", nil, ruleError(ErrBadRule, data.Field, "aggregate %s requires a field", data.Aggregate)
+	}
+
+	if !data.trusted {
+		if err := rc.checkPolicies(data); err != nil {
+			return "", nil, err
+		}
+	}
+
+	name := fmt.Sprintf("(SELECT %s(%s) FROM %s%s)", fn, arg, rel.Table, relationWhere(rel, ""))
+	return operationSQL(name, false, data, config, rc)
+}
@@ -0,0 +1,113 @@
+package querysql
+
+import (
+	"context"
+	"fmt"
+)
+
+// RenderContext keeps the state of rendering, such as the numbering of placeholders.
+// A new context is created for every GetSQL call, so drivers can be shared between goroutines.
+// Several filters rendered with the same context continue the numbering of each other.
+type RenderContext struct {
+	DB     DBDriver
+	Config *SQLConfig
+
+	// Ctx is passed to the context variants of hooks, it is never nil
+	Ctx context.Context
+
+	// Principal is passed to policies of the config
+	Principal *Principal
+
+	// Start is the index of the first placeholder, it allows to add the filter
+	// to a query which already has parameters. Zero value means 1.
+	Start int
+
+	count  int
+	inline bool
+
+	denyFields     map[string]bool
+	denyOperations map[string]bool
+}
+
+func NewRenderContext(config *SQLConfig, db DBDriver) *RenderContext {
+	if db == nil {
+		db = MySQL{}
+	}
+
+	return &RenderContext{DB: db, Config: config, Ctx: context.Background()}
+}
+
+// Mark allocates the next placeholder
+func (rc *RenderContext) Mark() string {
+	index := rc.index()
+	rc.count++
+
+	if rc.inline {
+		return inlineMark(index)
+	}
+	if rc.Config != nil && rc.Config.NamedParams != nil {
+		return rc.Config.NamedParams.mark(index)
+	}
+	return rc.DB.Mark(index)
+}
+
+func (rc *RenderContext) index() int {
+	if rc.Start == 0 {
+		return 1 + rc.count
+	}
+	return rc.Start + rc.count
+}
+
+// anonymousMarks checks whether all placeholders are the same, so values are bound by position
+func (rc *RenderContext) anonymousMarks() bool {
+	if rc.inline || rc.Config != nil && rc.Config.NamedParams != nil {
+		return false
+	}
+	return rc.DB.Mark(1) == rc.DB.Mark(2)
+}
+
+// Count returns the number of placeholders allocated by the context
+func (rc *RenderContext) Count() int {
+	return rc.count
+}
+
+func (rc *RenderContext) GetSQL(data Filter) (string, []interface{}, error) {
+	if err := rc.Ctx.Err(); err != nil {
+		return "", nil, err
+	}
+
+	if err := checkLimits(data, rc.Config); err != nil {
+		return "", nil, err
+	}
+
+	data, err := rc.applyPolicies(data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	first := rc.index()
+	sql, values, err := getSQL(data, rc.Config, rc, "")
+	if err == nil {
+		err = checkTrustedSQL(data, sql, "")
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	// custom operations can add more parameters than expected
+	if rc.Config != nil && rc.Config.Limits != nil {
+		if err := checkParams(len(values), rc.Config.Limits, ""); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if rc.Config != nil && rc.Config.NamedParams != nil && !rc.inline {
+		// names are assigned by position, so each value must have its own placeholder
+		if len(values) != rc.index()-first {
+			return "", nil, fmt.Errorf("number of values doesn't match number of named parameters: %d != %d", len(values), rc.index()-first)
+		}
+		values = rc.Config.NamedParams.namedValues(values, first)
+	}
+
+	return sql, values, nil
+}
@@ -0,0 +1,43 @@
+package querysql
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRenderContext(t *testing.T) {
+	format, err := FromJSON([]byte(aAndB))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", aAndB, err)
+		return
+	}
+
+	// the same driver value can be reused and shared between goroutines
+	db := &PostgreSQL{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sql, _, err := GetSQL(format, nil, db)
+			if err != nil || sql != "( a < $1 AND b > $2 )" {
+				t.Errorf("wrong sql generated\nj: %s\nr: %s %v", aAndB, sql, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	db.Reset()
+	sql, _, _ := GetSQL(format, nil, db)
+	if sql != "( a < $1 AND b > $2 )" {
+		t.Errorf("wrong sql generated after reset\nj: %s\nr: %s", aAndB, sql)
+	}
+
+	rc := NewRenderContext(nil, PostgreSQL{})
+	rc.Start = 3
+	first, _, _ := rc.GetSQL(format)
+	second, _, _ := rc.GetSQL(format)
+	if first != "( a < $3 AND b > $4 )" || second != "( a < $5 AND b > $6 )" || rc.Count() != 4 {
+		t.Errorf("wrong numbering of placeholders\nr: %s\nr: %s", first, second)
+	}
+}
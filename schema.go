@@ -0,0 +1,215 @@
+package querysql
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	TypeText    = "text"
+	TypeNumber  = "number"
+	TypeDate    = "date"
+	TypeBoolean = "boolean"
+)
+
+// FieldSchema describes a single field of SQLConfig.Schema.
+// Empty Filters allow the operations which make sense for the Type,
+// empty Predicates allow any predicate, zero MaxIncludes means no limit.
+// "includes" in Filters controls whether the field accepts an include list.
+// Label and Options are used only by QueryBuilderFields.
+type FieldSchema struct {
+	Type        string
+	Filters     []string
+	Predicates  []string
+	Nullable    bool
+	MaxIncludes int
+	Label       string
+	Options     []Option
+}
+
+type Option struct {
+	ID    interface{} `json:"id"`
+	Value string      `json:"value"`
+}
+
+var compareFilters = []string{"equal", "notEqual", "less", "lessOrEqual", "greater", "greaterOrEqual", "between", "notBetween", "includes"}
+
+var typeFilters = map[string][]string{
+	TypeNumber:  compareFilters,
+	TypeDate:    compareFilters,
+	TypeBoolean: {"equal", "notEqual", "includes"},
+}
+
+var builtinFilters = []string{
+	"equal", "notEqual", "less", "lessOrEqual", "greater", "greaterOrEqual", "between", "notBetween",
+	"contains", "notContains", "beginsWith", "notBeginsWith", "endsWith", "notEndsWith", "includes",
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "2006/01/02"}
+
+func getFieldSchema(name string, config *SQLConfig) (FieldSchema, bool) {
+	if config == nil || config.Schema == nil {
+		return FieldSchema{}, false
+	}
+
+	fs, ok := config.Schema[name]
+	return fs, ok
+}
+
+func contains(list []string, name string) bool {
+	for _, x := range list {
+		if x == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (fs FieldSchema) allowsFilter(name string) bool {
+	if fs.Filters != nil {
+		return contains(fs.Filters, name)
+	}
+
+	if allowed, ok := typeFilters[fs.Type]; ok && contains(builtinFilters, name) {
+		return contains(allowed, name)
+	}
+	return true
+}
+
+// checkSchema validates the rule against the field schema and converts
+// the values to the type of the field
+func checkSchema(data Filter, config *SQLConfig) (Filter, error) {
+	fs, ok := getFieldSchema(data.Field, config)
+	if !ok {
+		return data, nil
+	}
+
+	for _, predicate := range predicateChain(data.Predicate) {
+		if fs.Predicates != nil && !contains(fs.Predicates, predicate) {
+			return data, ruleError(ErrPredicateNotAllowed, data.Field, "%s for field %s", predicate, data.Field)
+		}
+	}
+
+	tp, err := predicateOutput(fs, data, config)
+	if err != nil {
+		return data, err
+	}
+
+	if data.Includes != nil {
+		if !fs.allowsFilter("includes") {
+			return data, ruleError(ErrOperationNotAllowed, data.Field, "includes for field %s", data.Field)
+		}
+		if fs.MaxIncludes > 0 && len(data.Includes) > fs.MaxIncludes {
+			return data, ruleError(ErrBadValue, data.Field, "field %s allows at most %d included values, got %d", data.Field, fs.MaxIncludes, len(data.Includes))
+		}
+
+		includes := make([]interface{}, len(data.Includes))
+		for i, v := range data.Includes {
+			cv, err := fs.coerce(data.Field, tp, v)
+			if err != nil {
+				return data, err
+			}
+			includes[i] = cv
+		}
+		data.Includes = includes
+		return data, nil
+	}
+
+	if data.Filter == "" {
+		return data, nil
+	}
+
+	if !fs.allowsFilter(data.Filter) {
+		return data, ruleError(ErrOperationNotAllowed, data.Field, "%s for field %s", data.Filter, data.Field)
+	}
+
+	valueMap, isMap := data.Value.(map[string]interface{})
+	if _, isRef := fieldReference(data.Value); !isMap || isRef {
+		v, err := fs.coerce(data.Field, tp, data.Value)
+		data.Value = v
+		return data, err
+	}
+
+	// one side of between can be omitted
+	if valueMap["start"] == nil && valueMap["end"] == nil && !fs.Nullable {
+		return data, ruleError(ErrBadValue, data.Field, "field %s requires start or end value for %s operation", data.Field, data.Filter)
+	}
+
+	out := make(map[string]interface{}, 2)
+	for _, key := range []string{"start", "end"} {
+		if v := valueMap[key]; v != nil {
+			cv, err := fs.coerce(data.Field, tp, v)
+			if err != nil {
+				return data, err
+			}
+			out[key] = cv
+		}
+	}
+	data.Value = out
+
+	return data, nil
+}
+
+// coerce converts the value to tp, which is the type of the field after predicates
+func (fs FieldSchema) coerce(field, tp string, v interface{}) (interface{}, error) {
+	if v == nil {
+		if !fs.Nullable {
+			return nil, ruleError(ErrBadValue, field, "field %s doesn't accept null values", field)
+		}
+		return nil, nil
+	}
+
+	// type is unknown after custom predicates, field references are checked by the whitelist
+	if _, isRef := fieldReference(v); isRef || tp == "" {
+		return v, nil
+	}
+
+	cv, ok := coerceValue(v, tp)
+	if !ok {
+		return nil, ruleError(ErrBadValue, field, "field %s expects a %s value, got %v", field, tp, v)
+	}
+	return cv, nil
+}
+
+// coerceValue converts the value received from JSON to the type of the field,
+// numbers and booleans can be sent as strings, dates are converted to time.Time
+func coerceValue(v interface{}, tp string) (interface{}, bool) {
+	switch tp {
+	case TypeText:
+		_, ok := v.(string)
+		return v, ok
+	case TypeNumber:
+		switch n := v.(type) {
+		case float64, float32, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, json.Number:
+			return v, true
+		case string:
+			f, err := strconv.ParseFloat(n, 64)
+			return f, err == nil
+		}
+		return v, false
+	case TypeBoolean:
+		switch b := v.(type) {
+		case bool:
+			return v, true
+		case string:
+			x, err := strconv.ParseBool(b)
+			return x, err == nil
+		}
+		return v, false
+	case TypeDate:
+		switch d := v.(type) {
+		case time.Time:
+			return v, true
+		case string:
+			for _, layout := range dateLayouts {
+				if t, err := time.Parse(layout, d); err == nil {
+					return t, true
+				}
+			}
+		}
+		return v, false
+	}
+
+	return v, true
+}
@@ -0,0 +1,100 @@
+package querysql
+
+import (
+	"testing"
+)
+
+var schemaConfig = SQLConfig{
+	Schema: map[string]FieldSchema{
+		"id":      {Type: TypeNumber, MaxIncludes: 3},
+		"name":    {Type: TypeText, Filters: []string{"equal", "contains"}},
+		"created": {Type: TypeDate, Predicates: []string{"year"}, Nullable: true},
+		"active":  {Type: TypeBoolean},
+	},
+	Predicates: map[string]CustomPredicate{
+		"year":  func(n string, p string) (string, error) { return "YEAR(" + n + ")", nil },
+		"month": func(n string, p string) (string, error) { return "MONTH(" + n + ")", nil },
+	},
+}
+
+func TestSchema(t *testing.T) {
+	valid := []string{
+		`{ "field": "id", "filter":"less", "predicate":"year", "value":1 }`,
+		`{ "field": "id", "predicate":"year", "includes":[1,2,3] }`,
+		`{ "field": "name", "predicate":"year", "filter":"contains", "value":"a" }`,
+		`{ "field": "created", "predicate":"year", "filter":"between", "value":{ "start":2020 } }`,
+		`{ "field": "created", "predicate":"", "filter":"greater", "value":"2024-01-15T00:00:00.000Z" }`,
+		`{ "field": "created", "predicate":"", "filter":"equal", "value":null }`,
+		`{ "field": "active", "predicate":"year", "filter":"equal", "value":true }`,
+	}
+	invalid := []string{
+		`{ "field": "id", "predicate":"year", "filter":"contains", "value":1 }`,
+		`{ "field": "id", "predicate":"", "filter":"equal", "value":"abc" }`,
+		`{ "field": "id", "predicate":"year", "filter":"equal", "value":null }`,
+		`{ "field": "id", "predicate":"year", "includes":[1,2,3,4] }`,
+		`{ "field": "name", "predicate":"year", "filter":"beginsWith", "value":"a" }`,
+		`{ "field": "created", "predicate":"month", "filter":"equal", "value":1 }`,
+		`{ "field": "created", "predicate":"", "filter":"equal", "value":"yesterday" }`,
+		`{ "field": "active", "predicate":"year", "filter":"less", "value":true }`,
+		`{ "field": "other", "predicate":"year", "filter":"equal", "value":1 }`,
+	}
+
+	for _, text := range valid {
+		format, err := FromJSON([]byte(text))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", text, err)
+			continue
+		}
+
+		if _, _, err := GetSQL(format, &schemaConfig); err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+		}
+	}
+
+	for _, text := range invalid {
+		format, err := FromJSON([]byte(text))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", text, err)
+			continue
+		}
+
+		if _, _, err := GetSQL(format, &schemaConfig); err == nil {
+			t.Errorf("doesn't return error for a rule which violates the schema\nj: %s", text)
+		}
+	}
+}
+
+func TestSchemaNull(t *testing.T) {
+	checks := [][]string{
+		{`{ "field": "created", "filter":"equal", "value":null }`, "created IS NULL"},
+		{`{ "field": "created", "filter":"notEqual", "value":null }`, "created IS NOT NULL"},
+		{`{ "glue":"and", "rules":[{ "field": "created", "filter":"equal", "value":null }, { "field": "id", "filter":"equal", "value":1 }]}`, "( created IS NULL AND id = $1 )"},
+	}
+
+	for _, c := range checks {
+		format, err := FromJSON([]byte(c[0]))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", c[0], err)
+			continue
+		}
+
+		sql, values, err := GetSQL(format, &schemaConfig, PostgreSQL{})
+		if err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", c[0], err)
+			continue
+		}
+		if sql != c[1] {
+			t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", c[0], c[1], sql)
+		}
+		for _, v := range values {
+			if v == nil {
+				t.Errorf("null is bound as a value\nj: %s", c[0])
+			}
+		}
+	}
+
+	format, _ := FromJSON([]byte(`{ "field": "created", "filter":"less", "value":null }`))
+	if _, _, err := GetSQL(format, &schemaConfig); err == nil {
+		t.Errorf("less accepts null value")
+	}
+}
@@ -0,0 +1,34 @@
+package querysql
+
+// Trusted marks the filter as created by the server, its rules skip the whitelist,
+// schema and limits checks. Filters parsed from JSON are never trusted.
+func Trusted(f Filter) Filter {
+	f.trusted = true
+	return f
+}
+
+// checkTrustedSQL rejects trusted rules which render to nothing, such a rule
+// is a server side restriction and must not turn into a filter which matches all rows
+func checkTrustedSQL(data Filter, sql string, path string) error {
+	if !data.trusted || sql != "" {
+		return nil
+	}
+	return &RuleError{Path: path, Field: data.Field, Err: ErrBadRule, Detail: "trusted rule has no condition"}
+}
+
+// Scope combines mandatory server conditions with the filter received from the client.
+// Scopes are trusted, the client filter is checked as usual and always wrapped in parentheses,
+// so it can't escape the AND with the scopes.
+//
+//	filter := querysql.Scope(clientFilter, querysql.Field("tenant_id").Equal(tenant))
+func Scope(user Filter, scopes ...Filter) Filter {
+	rules := make([]Filter, 0, len(scopes)+1)
+	for _, s := range scopes {
+		rules = append(rules, Trusted(s))
+	}
+
+	// paths of errors in the client filter start from its own root
+	user.isolated = true
+
+	return Filter{Glue: "and", Rules: append(rules, user)}
+}
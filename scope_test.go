@@ -0,0 +1,79 @@
+package querysql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	config := &SQLConfig{
+		Whitelist: map[string]bool{"a": true, "b": true},
+		Limits:    &Limits{MaxRules: 2},
+	}
+	scopes := []Filter{Field("tenant_id").Equal(7), Field("deleted").Equal(false)}
+
+	cases := [][]string{
+		{aOrB, "( tenant_id = $1 AND deleted = $2 AND ( a < $3 OR b > $4 ) )"},
+		{`{ "field": "a", "filter":"less", "value":1 }`, "( tenant_id = $1 AND deleted = $2 AND ( a < $3 ) )"},
+		{`{ "glue":"or", "rules":[{ "field": "a", "filter":"less", "value":1 }]}`, "( tenant_id = $1 AND deleted = $2 AND ( a < $3 ) )"},
+		{`{}`, "( tenant_id = $1 AND deleted = $2 )"},
+	}
+
+	for _, line := range cases {
+		user, err := FromJSON([]byte(line[0]))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", line[0], err)
+			continue
+		}
+
+		sql, _, err := GetSQL(Scope(user, scopes...), config, PostgreSQL{})
+		if err != nil {
+			t.Errorf("can't generate sql\nj: %s\n%s", line[0], err)
+			continue
+		}
+		if sql != line[1] {
+			t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", line[0], line[1], sql)
+		}
+	}
+
+	user, _ := FromJSON([]byte(`{ "glue":"or", "rules":[{ "field": "a", "filter":"less", "value":1 }, { "field": "tenant_id", "filter":"greater", "value":0 }]}`))
+	_, _, err := GetSQL(Scope(user, scopes...), config)
+	if !errors.Is(err, ErrFieldNotAllowed) {
+		t.Errorf("client filter is not checked by the whitelist\nr: %v", err)
+	}
+
+	// paths point into the client filter, like the ones returned by Validate
+	paths := [][]string{
+		{`{ "glue":"or", "rules":[{ "field": "a", "filter":"less", "value":1 }, { "field": "c", "filter":"less", "value":1 }]}`, "rules[1]"},
+		{`{ "field": "c", "filter":"less", "value":1 }`, ""},
+	}
+	for _, line := range paths {
+		user, _ := FromJSON([]byte(line[0]))
+		var re *RuleError
+		_, _, err := GetSQL(Scope(user, scopes...), config)
+		if !errors.As(err, &re) || re.Path != line[1] {
+			t.Errorf("wrong path of the error\nj: %s\ns: %s\nr: %v", line[0], line[1], err)
+		}
+
+		errs := Validate(Scope(user, scopes...), config)
+		if len(errs) != 1 || !errors.As(errs[0], &re) || re.Path != line[1] {
+			t.Errorf("wrong path of the validation error\nj: %s\ns: %s\nr: %v", line[0], line[1], errs)
+		}
+	}
+}
+
+func TestScopeWithoutCondition(t *testing.T) {
+	config := &SQLConfig{Whitelist: map[string]bool{"a": true}}
+	user, _ := FromJSON([]byte(`{ "field": "a", "filter":"equal", "value":1 }`))
+
+	for _, scope := range []Filter{Field("tenant").Is("", 5), {}, And()} {
+		_, _, err := GetSQL(Scope(user, scope), config)
+		if !errors.Is(err, ErrBadRule) {
+			t.Errorf("scope without condition is not rejected\nf: %+v\nr: %v", scope, err)
+		}
+	}
+
+	if _, _, err := GetSQL(Trusted(Field("tenant").Is("", 5)), config); !errors.Is(err, ErrBadRule) {
+		t.Errorf("trusted root rule without condition is not rejected\nr: %v", err)
+	}
+}
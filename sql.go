@@ -1,32 +1,50 @@
 package querysql
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 )
 
+// DBDriver generates dialect specific SQL, drivers must not keep any state.
+// Mark returns the placeholder for the parameter with the given 1-based index,
+// other methods receive the placeholder of their parameter.
 type DBDriver interface {
-	Mark() string
+	// Dialect returns the key of SQLConfig.Dialects used with the driver
+	Dialect() string
+	Mark(index int) string
 	IsJSON(name string) (string, bool)
 
-	Contains(v string, isJSON bool) string
-	NotContains(v string, isJSON bool) string
-	BeginsWith(v string, isJSON bool) string
-	NotBeginsWith(v string, isJSON bool) string
-	EndsWith(v string, isJSON bool) string
-	NotEndsWith(v string, isJSON bool) string
+	Contains(v string, mark string, isJSON bool) string
+	NotContains(v string, mark string, isJSON bool) string
+	BeginsWith(v string, mark string, isJSON bool) string
+	NotBeginsWith(v string, mark string, isJSON bool) string
+	EndsWith(v string, mark string, isJSON bool) string
+	NotEndsWith(v string, mark string, isJSON bool) string
+
+	// Literal formats the value as an SQL literal, it is used only by DebugSQL
+	Literal(v interface{}) string
 }
 
 type Filter struct {
-	Glue      string        `json:"glue"`
-	Field     string        `json:"field"`
-	Type      string        `json:"type"`
-	Predicate string        `json:"predicate"`
-	Filter    string        `json:"filter"`
-	Value     interface{}   `json:"value"`
-	Includes  []interface{} `json:"includes"`
-	Rules     []Filter      `json:"rules"`
+	Glue      string `json:"glue"`
+	Field     string `json:"field"`
+	Type      string `json:"type"`
+	Predicate string `json:"predicate"`
+	// PredicateArgs are the arguments of each predicate of the chain, see Filter.UnmarshalJSON
+	PredicateArgs [][]interface{} `json:"-"`
+	Filter        string          `json:"filter"`
+	Value         interface{}     `json:"value"`
+	Includes      []interface{}   `json:"includes"`
+	Rules         []Filter        `json:"rules"`
+	Relation      string          `json:"relation"`
+	Aggregate     string          `json:"aggregate"`
+
+	// trusted rules are created by the server and skip the whitelist,
+	// isolated rules are always wrapped in parentheses, see Scope
+	trusted  bool
+	isolated bool
 }
 
 func (f *Filter) getValues() []interface{} {
@@ -34,6 +52,9 @@ func (f *Filter) getValues() []interface{} {
 	if !ok {
 		return []interface{}{f.Value}
 	}
+	if _, isRef := fieldReference(valueMap); isRef {
+		return []interface{}{f.Value}
+	}
 
 	return []interface{}{valueMap["start"], valueMap["end"]}
 }
@@ -41,12 +62,30 @@ func (f *Filter) getValues() []interface{} {
 type CustomOperation func(string, string, []interface{}) (string, []interface{}, error)
 type CustomPredicate func(string, string) (string, error)
 
+// Context variants of the hooks receive the context passed to GetSQLContext
+type CustomOperationContext func(context.Context, string, string, []interface{}) (string, []interface{}, error)
+type CustomPredicateContext func(context.Context, string, string) (string, error)
+
 type CheckFunction = func(string) bool
+type CheckFunctionContext = func(context.Context, string) bool
 type SQLConfig struct {
-	WhitelistFunc CheckFunction
-	Whitelist     map[string]bool
-	Operations    map[string]CustomOperation
-	Predicates    map[string]CustomPredicate
+	WhitelistFunc        CheckFunction
+	WhitelistFuncContext CheckFunctionContext
+	Whitelist            map[string]bool
+	Operations           map[string]CustomOperation
+	OperationsContext    map[string]CustomOperationContext
+	Predicates           map[string]CustomPredicate
+	PredicatesContext    map[string]CustomPredicateContext
+	RuleOperations       map[string]RuleOperation
+	RulePredicates       map[string]RulePredicate
+	PredicateTypes       map[string]PredicateType
+	Dialects             map[string]DialectConfig
+	Relations            map[string]Relation
+	Aliases              map[string]string
+	Schema               map[string]FieldSchema
+	Limits               *Limits
+	NamedParams          *NamedParams
+	Policies             []Policy
 }
 
 func FromJSON(text []byte) (Filter, error) {
@@ -58,10 +97,10 @@ func FromJSON(text []byte) (Filter, error) {
 
 var NoValues = make([]interface{}, 0)
 
-func inSQL(field string, data []interface{}, db DBDriver) (string, []interface{}, error) {
+func inSQL(field string, data []interface{}, rc *RenderContext) (string, []interface{}, error) {
 	marks := make([]string, len(data))
 	for i := range marks {
-		marks[i] = db.Mark()
+		marks[i] = rc.Mark()
 	}
 
 	sql := fmt.Sprintf("%s IN(%s)", field, strings.Join(marks, ","))
@@ -76,106 +115,72 @@ func GetSQL(data Filter, config *SQLConfig, dbArr ...DBDriver) (string, []interf
 		db = MySQL{}
 	}
 
+	return NewRenderContext(config, db).GetSQL(data)
+}
+
+func getSQL(data Filter, config *SQLConfig, rc *RenderContext, path string) (string, []interface{}, error) {
+	if data.Relation != "" {
+		sql, values, err := relationSQL(data, config, rc, path)
+		if data.isolated && data.Rules == nil && sql != "" {
+			sql = "( " + sql + " )"
+		}
+		return sql, values, err
+	}
+
 	if data.Rules == nil {
 		if data.Field == "" {
 			return "", make([]interface{}, 0), nil
 		}
 
-		if !checkWhitelist(data.Field, config) {
-			return "", nil, fmt.Errorf("field name is not in whitelist: %s", data.Field)
-		}
-
-		name, isDynamicField := db.IsJSON(data.Field)
-
-		if len(data.Includes) > 0 {
-			return inSQL(name, data.Includes, db)
+		if !data.trusted && !checkWhitelist(rc.Ctx, data.Field, config) {
+			return "", nil, &RuleError{Path: path, Field: data.Field, Err: ErrFieldNotAllowed, Detail: data.Field}
 		}
 
-		values := data.getValues()
-
 		var err error
-		if config != nil && config.Predicates != nil {
-			if pr, prOk := config.Predicates[data.Predicate]; prOk {
-				name, err = pr(name, data.Predicate)
-				if err != nil {
-					return "", NoValues, err
-				}
-			} else {
-				return "", NoValues, fmt.Errorf("unknown predicate: %s", data.Predicate)
-			}
-		}
-
-		switch data.Filter {
-		case "":
-			return "", NoValues, nil
-		case "equal":
-			return fmt.Sprintf("%s = %s", name, db.Mark()), values, nil
-		case "notEqual":
-			return fmt.Sprintf("%s <> %s", name, db.Mark()), values, nil
-		case "contains":
-			return db.Contains(name, isDynamicField), values, nil
-		case "notContains":
-			return db.NotContains(name, isDynamicField), values, nil
-		case "lessOrEqual":
-			return fmt.Sprintf("%s <= %s", name, db.Mark()), values, nil
-		case "greaterOrEqual":
-			return fmt.Sprintf("%s >= %s", name, db.Mark()), values, nil
-		case "less":
-			return fmt.Sprintf("%s < %s", name, db.Mark()), values, nil
-		case "notBetween":
-			if len(values) != 2 {
-				return "", nil, fmt.Errorf("wrong number of parameters for notBetween operation: %d", len(values))
-			}
-
-			if values[0] == nil {
-				return fmt.Sprintf("%s > %s", name, db.Mark()), values[1:], nil
-			} else if values[1] == nil {
-				return fmt.Sprintf("%s < %s", name, db.Mark()), values[:1], nil
-			} else {
-				return fmt.Sprintf("( %s < %s OR %s > %s )", name, db.Mark(), name, db.Mark()), values, nil
+		if !data.trusted {
+			data, err = checkSchema(data, config)
+			if err == nil {
+				err = rc.checkPolicies(data)
 			}
-		case "between":
-			if len(values) != 2 {
-				return "", nil, fmt.Errorf("wrong number of parameters for notBetween operation: %d", len(values))
-			}
-
-			if values[0] == nil {
-				return fmt.Sprintf("%s < %s", name, db.Mark()), values[1:], nil
-			} else if values[1] == nil {
-				return fmt.Sprintf("%s > %s", name, db.Mark()), values[:1], nil
-			} else {
-				return fmt.Sprintf("( %s > %s AND %s < %s )", name, db.Mark(), name, db.Mark()), values, nil
-			}
-		case "greater":
-			return fmt.Sprintf("%s > %s", name, db.Mark()), values, nil
-		case "beginsWith":
-			return db.BeginsWith(name, isDynamicField), values, nil
-		case "notBeginsWith":
-			return db.NotBeginsWith(name, isDynamicField), values, nil
-		case "endsWith":
-			return db.EndsWith(name, isDynamicField), values, nil
-		case "notEndsWith":
-			return db.NotEndsWith(name, isDynamicField), values, nil
-		}
-
-		if config != nil && config.Operations != nil {
-			if op, opOk := config.Operations[data.Filter]; opOk {
-				return op(name, data.Filter, values)
+			if err != nil {
+				return "", nil, withPath(err, path, data.Field)
 			}
 		}
 
-		return "", NoValues, fmt.Errorf("unknown operation: %s", data.Filter)
+		name, isDynamicField := resolveField(data.Field, config, rc.DB)
+		sql, values, err := operationSQL(name, isDynamicField, data, config, rc)
+		if err != nil {
+			return "", nil, withPath(err, path, data.Field)
+		}
+		if data.isolated && sql != "" {
+			sql = "( " + sql + " )"
+		}
+		return sql, values, nil
 	}
 
 	out := make([]string, 0, len(data.Rules))
 	values := make([]interface{}, 0)
 
-	for _, r := range data.Rules {
-		subSql, subValues, err := GetSQL(r, config, db)
+	for i, r := range data.Rules {
+		if data.trusted {
+			r.trusted = true
+		}
+
+		subPath := childPath(path, i)
+		if r.isolated {
+			subPath = ""
+		}
+
+		subSql, subValues, err := getSQL(r, config, rc, subPath)
+		if err == nil {
+			err = checkTrustedSQL(r, subSql, subPath)
+		}
 		if err != nil {
 			return "", nil, err
 		}
-		out = append(out, subSql)
+		if subSql != "" {
+			out = append(out, subSql)
+		}
 		values = append(values, subValues...)
 	}
 
@@ -187,18 +192,143 @@ func GetSQL(data Filter, config *SQLConfig, dbArr ...DBDriver) (string, []interf
 	}
 
 	outStr := strings.Join(out, glue)
-	if len(data.Rules) > 1 {
+	if len(out) > 1 || data.isolated && len(out) == 1 {
 		outStr = "( " + outStr + " )"
 	}
 
 	return outStr, values, nil
 }
 
-func checkWhitelist(name string, config *SQLConfig) bool {
-	if config == nil {
+func operationSQL(name string, isDynamicField bool, data Filter, config *SQLConfig, rc *RenderContext) (string, []interface{}, error) {
+	if data.Filter == "" && data.Includes == nil {
+		// rule without operation renders nothing, so the predicate must not allocate placeholders
+		return "", NoValues, nil
+	}
+
+	// values are checked before the predicate allocates its placeholders
+	values, hasRefs := data.getValues(), false
+	if data.Includes != nil && len(data.Includes) == 0 {
+		// x IN () is not valid SQL, the empty list matches no rows
+		return "1 = 0", NoValues, nil
+	}
+	if len(data.Includes) == 0 {
+		var err error
+		values, hasRefs, err = resolveReferences(data, values, config, rc)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if err := checkValueShape(data, values); err != nil {
+			return "", nil, err
+		}
+	}
+
+	name, fieldValues, err := predicateSQL(name, isDynamicField, data, config, rc)
+	if err != nil {
+		return "", NoValues, err
+	}
+
+	if len(data.Includes) > 0 {
+		sql, includes, _ := inSQL(name, data.Includes, rc)
+		return sql, append(append(make([]interface{}, 0, len(fieldValues)+len(includes)), fieldValues...), includes...), nil
+	}
+
+	sql, opValues, err := ruleSQL(name, isDynamicField, data, values, hasRefs, fieldValues, config, rc)
+	if err != nil || sql == "" {
+		return sql, opValues, err
+	}
+	return sql, withFieldValues(data, fieldValues, values, opValues, rc), nil
+}
+
+func ruleSQL(name string, isDynamicField bool, data Filter, values []interface{}, hasRefs bool, fieldValues []interface{}, config *SQLConfig, rc *RenderContext) (string, []interface{}, error) {
+	if hasRefs && data.Filter != "" {
+		return referenceSQL(name, data, values, rc)
+	}
+
+	switch data.Filter {
+	case "equal":
+		if values[0] == nil {
+			return fmt.Sprintf("%s IS NULL", name), NoValues, nil
+		}
+		return fmt.Sprintf("%s = %s", name, rc.Mark()), values, nil
+	case "notEqual":
+		if values[0] == nil {
+			return fmt.Sprintf("%s IS NOT NULL", name), NoValues, nil
+		}
+		return fmt.Sprintf("%s <> %s", name, rc.Mark()), values, nil
+	case "contains":
+		return rc.DB.Contains(name, rc.Mark(), isDynamicField), values, nil
+	case "notContains":
+		return rc.DB.NotContains(name, rc.Mark(), isDynamicField), values, nil
+	case "lessOrEqual":
+		return fmt.Sprintf("%s <= %s", name, rc.Mark()), values, nil
+	case "greaterOrEqual":
+		return fmt.Sprintf("%s >= %s", name, rc.Mark()), values, nil
+	case "less":
+		return fmt.Sprintf("%s < %s", name, rc.Mark()), values, nil
+	case "notBetween":
+		if len(values) != 2 {
+			return "", nil, ruleError(ErrBadValue, data.Field, "wrong number of parameters for notBetween operation: %d", len(values))
+		}
+
+		if values[0] == nil {
+			return fmt.Sprintf("%s > %s", name, rc.Mark()), values[1:], nil
+		} else if values[1] == nil {
+			return fmt.Sprintf("%s < %s", name, rc.Mark()), values[:1], nil
+		} else {
+			return fmt.Sprintf("( %s < %s OR %s > %s )", name, rc.Mark(), name, rc.Mark()), values, nil
+		}
+	case "between":
+		if len(values) != 2 {
+			return "", nil, ruleError(ErrBadValue, data.Field, "wrong number of parameters for between operation: %d", len(values))
+		}
+
+		if values[0] == nil {
+			return fmt.Sprintf("%s < %s", name, rc.Mark()), values[1:], nil
+		} else if values[1] == nil {
+			return fmt.Sprintf("%s > %s", name, rc.Mark()), values[:1], nil
+		} else {
+			return fmt.Sprintf("( %s > %s AND %s < %s )", name, rc.Mark(), name, rc.Mark()), values, nil
+		}
+	case "greater":
+		return fmt.Sprintf("%s > %s", name, rc.Mark()), values, nil
+	case "beginsWith":
+		return rc.DB.BeginsWith(name, rc.Mark(), isDynamicField), values, nil
+	case "notBeginsWith":
+		return rc.DB.NotBeginsWith(name, rc.Mark(), isDynamicField), values, nil
+	case "endsWith":
+		return rc.DB.EndsWith(name, rc.Mark(), isDynamicField), values, nil
+	case "notEndsWith":
+		return rc.DB.NotEndsWith(name, rc.Mark(), isDynamicField), values, nil
+	}
+
+	call := OperationCall{Rule: data, Field: name, IsJSON: isDynamicField, Values: values, FieldValues: fieldValues}
+	if d, ok := getDialect(config, rc.DB); ok {
+		if op, opOk := d.Operations[data.Filter]; opOk {
+			return op(rc, call)
+		}
+	}
+	if config != nil {
+		if op, opOk := config.Operations[data.Filter]; opOk {
+			return op(name, data.Filter, values)
+		}
+		if op, opOk := config.OperationsContext[data.Filter]; opOk {
+			return op(rc.Ctx, name, data.Filter, values)
+		}
+		if op, opOk := config.RuleOperations[data.Filter]; opOk {
+			return op(rc, call)
+		}
+	}
+
+	return "", NoValues, ruleError(ErrUnknownOperation, data.Field, "%s", data.Filter)
+}
+
+func checkWhitelist(ctx context.Context, name string, config *SQLConfig) bool {
+	if !hasWhitelist(config) {
 		return true
 	}
-	if config.Whitelist == nil && config.WhitelistFunc == nil {
+
+	if _, ok := config.Schema[name]; ok {
 		return true
 	}
 
@@ -208,10 +338,54 @@ func checkWhitelist(name string, config *SQLConfig) bool {
 		}
 	}
 
-	if config.WhitelistFunc != nil {
-		return config.WhitelistFunc(name)
+	if config.WhitelistFunc != nil && config.WhitelistFunc(name) {
+		return true
+	}
+
+	if config.WhitelistFuncContext != nil {
+		return config.WhitelistFuncContext(ctx, name)
 	}
 
 	return false
 
 }
+
+// hasWhitelist checks whether the config restricts the names of fields
+func hasWhitelist(config *SQLConfig) bool {
+	return config != nil && (config.Whitelist != nil || config.WhitelistFunc != nil || config.WhitelistFuncContext != nil || config.Schema != nil)
+}
+
+// checkValueShape ensures that built-in operations receive the expected number of values
+func checkValueShape(data Filter, values []interface{}) error {
+	if data.Filter == "" || !contains(builtinFilters, data.Filter) {
+		return nil
+	}
+
+	if data.Filter != "between" && data.Filter != "notBetween" {
+		if len(values) != 1 {
+			return ruleError(ErrBadValue, data.Field, "%s operation expects a single value", data.Filter)
+		}
+		// null is compared by IS NULL, other operations never match it
+		if values[0] == nil && data.Filter != "equal" && data.Filter != "notEqual" {
+			return ruleError(ErrBadValue, data.Field, "%s operation doesn't accept null value", data.Filter)
+		}
+		return nil
+	}
+
+	if len(values) == 2 && values[0] == nil && values[1] == nil {
+		return ruleError(ErrBadValue, data.Field, "%s operation requires start or end value", data.Filter)
+	}
+	return nil
+}
+
+// resolveField converts a whitelisted field name to the column or expression
+// which is used in the generated SQL
+func resolveField(name string, config *SQLConfig, db DBDriver) (string, bool) {
+	if config != nil && config.Aliases != nil {
+		if alias, ok := config.Aliases[name]; ok {
+			name = alias
+		}
+	}
+
+	return db.IsJSON(name)
+}
@@ -1,6 +1,7 @@
 package querysql
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -571,3 +572,306 @@ func TestCustomPredicatePG(t *testing.T) {
 		return
 	}
 }
+
+var itemsRelation = SQLConfig{
+	Whitelist: map[string]bool{"id": true},
+	Relations: map[string]Relation{
+		"items": {
+			Table:  "order_items i",
+			Join:   "i.order_id = o.id",
+			Config: &SQLConfig{Whitelist: map[string]bool{"i.sku": true, "i.qty": true}},
+		},
+	},
+}
+
+var relationCases = [][]string{
+	{
+		`{ "relation":"items", "glue":"and", "rules":[{ "field": "i.sku", "filter":"beginsWith", "value":"X" }]}`,
+		"EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id AND i.sku LIKE CONCAT(?, '%'))",
+		"EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id AND i.sku LIKE $1 || '%')",
+		"X",
+	},
+	{
+		`{ "glue":"and", "rules":[{ "field": "id", "filter":"greater", "value":1 }, { "relation":"items", "glue":"or", "rules":[{ "field": "i.sku", "filter":"equal", "value":"X" }, { "field": "i.qty", "filter":"greater", "value":2 }]}]}`,
+		"( id > ? AND EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id AND ( i.sku = ? OR i.qty > ? )) )",
+		"( id > $1 AND EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id AND ( i.sku = $2 OR i.qty > $3 )) )",
+		"1,X,2",
+	},
+	{
+		`{ "relation":"items", "field": "i.qty", "filter":"less", "value":5 }`,
+		"EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id AND i.qty < ?)",
+		"EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id AND i.qty < $1)",
+		"5",
+	},
+	{
+		`{ "relation":"items", "rules":[] }`,
+		"EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id)",
+		"EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id)",
+		"",
+	},
+}
+
+func TestRelation(t *testing.T) {
+	for _, line := range relationCases {
+		format, err := FromJSON([]byte(line[0]))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", line[0], err)
+			continue
+		}
+
+		for i, db := range []DBDriver{MySQL{}, &PostgreSQL{}} {
+			sql, vals, err := GetSQL(format, &itemsRelation, db)
+			if err != nil {
+				t.Errorf("can't generate sql\nj: %s\n%f", line[0], err)
+				continue
+			}
+			if sql != line[i+1] {
+				t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", line[0], line[i+1], sql)
+				continue
+			}
+
+			valsStr, err := anyToStringArray(vals)
+			if err != nil {
+				t.Errorf("can't convert parameters\nj: %s\n%f", line[0], err)
+				continue
+			}
+
+			if valsStr != line[3] {
+				t.Errorf("wrong sql generated (values)\nj: %s\ns: %s\nr: %s", line[0], line[3], valsStr)
+			}
+		}
+	}
+}
+
+func TestRelationWhitelist(t *testing.T) {
+	for _, text := range []string{
+		`{ "relation":"items", "rules":[{ "field": "id", "filter":"equal", "value":1 }]}`,
+		`{ "relation":"orders", "rules":[{ "field": "i.sku", "filter":"equal", "value":1 }]}`,
+		`{ "glue":"and", "rules":[{ "field": "i.sku", "filter":"equal", "value":1 }]}`,
+	} {
+		format, err := FromJSON([]byte(text))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", text, err)
+			continue
+		}
+
+		_, _, err = GetSQL(format, &itemsRelation)
+		if err == nil {
+			t.Errorf("doesn't return error for a field outside of the relation\nj: %s", text)
+		}
+	}
+}
+
+func TestRelationWithoutWhitelist(t *testing.T) {
+	config := &SQLConfig{
+		Whitelist: map[string]bool{"id": true},
+		Relations: map[string]Relation{"items": {Table: "items", Join: "items.oid = o.id"}},
+	}
+
+	text := `{ "relation":"items", "field":"1=1) OR (SELECT pg_sleep(10)", "filter":"equal", "value":1 }`
+	format, err := FromJSON([]byte(text))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", text, err)
+		return
+	}
+
+	if _, _, err := GetSQL(format, config, PostgreSQL{}); !errors.Is(err, ErrFieldNotAllowed) {
+		t.Errorf("relation without whitelist accepts any field\nj: %s\nr: %v", text, err)
+	}
+	if errs := Validate(format, config); len(errs) != 1 || !errors.Is(errs[0], ErrFieldNotAllowed) {
+		t.Errorf("relation without whitelist is valid\nj: %s\nr: %v", text, errs)
+	}
+
+	aggregate := `{ "relation":"items", "aggregate":"sum", "field":"(SELECT password FROM users LIMIT 1)", "filter":"greater", "value":1 }`
+	format, _ = FromJSON([]byte(aggregate))
+	if _, _, err := GetSQL(format, config, PostgreSQL{}); !errors.Is(err, ErrFieldNotAllowed) {
+		t.Errorf("aggregate of relation without whitelist accepts any field\nj: %s\nr: %v", aggregate, err)
+	}
+	if errs := Validate(format, config); len(errs) != 1 || !errors.Is(errs[0], ErrFieldNotAllowed) {
+		t.Errorf("aggregate of relation without whitelist is valid\nj: %s\nr: %v", aggregate, errs)
+	}
+
+	// server side rules are not checked
+	if _, _, err := GetSQL(Trusted(Exists("items", Field("sku").Equal("X"))), config); err != nil {
+		t.Errorf("trusted rule is rejected\n%s", err)
+	}
+}
+
+var ordersRelation = SQLConfig{
+	Relations: map[string]Relation{
+		"orders": {
+			Table:  "orders o",
+			Join:   "o.customer_id = c.id",
+			Config: &SQLConfig{Whitelist: map[string]bool{"o.total": true}},
+		},
+	},
+}
+
+var aggregateCases = [][]string{
+	{
+		`{ "relation":"orders", "aggregate":"count", "filter":"greater", "value":5 }`,
+		"(SELECT COUNT(*) FROM orders o WHERE o.customer_id = c.id) > ?",
+		"(SELECT COUNT(*) FROM orders o WHERE o.customer_id = c.id) > $1",
+		"5",
+	},
+	{
+		`{ "glue":"and", "rules":[{ "relation":"orders", "aggregate":"sum", "field":"o.total", "filter":"between", "value":{ "start":100, "end":200 } }]}`,
+		"( (SELECT SUM(o.total) FROM orders o WHERE o.customer_id = c.id) > ? AND (SELECT SUM(o.total) FROM orders o WHERE o.customer_id = c.id) < ? )",
+		"( (SELECT SUM(o.total) FROM orders o WHERE o.customer_id = c.id) > $1 AND (SELECT SUM(o.total) FROM orders o WHERE o.customer_id = c.id) < $2 )",
+		"100,200",
+	},
+	{
+		`{ "relation":"orders", "aggregate":"max", "field":"o.total", "includes":[1,2] }`,
+		"(SELECT MAX(o.total) FROM orders o WHERE o.customer_id = c.id) IN(?,?)",
+		"(SELECT MAX(o.total) FROM orders o WHERE o.customer_id = c.id) IN($1,$2)",
+		"1,2",
+	},
+}
+
+func TestAggregate(t *testing.T) {
+	for _, line := range aggregateCases {
+		format, err := FromJSON([]byte(line[0]))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", line[0], err)
+			continue
+		}
+
+		for i, db := range []DBDriver{MySQL{}, &PostgreSQL{}} {
+			sql, vals, err := GetSQL(format, &ordersRelation, db)
+			if err != nil {
+				t.Errorf("can't generate sql\nj: %s\n%f", line[0], err)
+				continue
+			}
+			if sql != line[i+1] {
+				t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", line[0], line[i+1], sql)
+				continue
+			}
+
+			valsStr, err := anyToStringArray(vals)
+			if err != nil {
+				t.Errorf("can't convert parameters\nj: %s\n%f", line[0], err)
+				continue
+			}
+
+			if valsStr != line[3] {
+				t.Errorf("wrong sql generated (values)\nj: %s\ns: %s\nr: %s", line[0], line[3], valsStr)
+			}
+		}
+	}
+
+	for _, text := range []string{
+		`{ "relation":"orders", "aggregate":"sum", "filter":"greater", "value":5 }`,
+		`{ "relation":"orders", "aggregate":"median", "field":"o.total", "filter":"greater", "value":5 }`,
+		`{ "relation":"orders", "aggregate":"sum", "field":"o.secret", "filter":"greater", "value":5 }`,
+	} {
+		format, _ := FromJSON([]byte(text))
+		if _, _, err := GetSQL(format, &ordersRelation); err == nil {
+			t.Errorf("doesn't return error for a wrong aggregate\nj: %s", text)
+		}
+	}
+}
+
+var referenceCases = [][]string{
+	{
+		`{ "field": "shipped_at", "filter":"greater", "value":{ "field":"ordered_at" } }`,
+		"shipped_at > ordered_at",
+		"shipped_at > ordered_at",
+		"",
+	},
+	{
+		`{ "field": "price", "filter":"between", "value":{ "start":10, "end":{ "field":"list_price" } } }`,
+		"( price > ? AND price < list_price )",
+		"( price > $1 AND price < list_price )",
+		"10",
+	},
+	{
+		`{ "field": "price", "filter":"notBetween", "value":{ "start":{ "field":"list_price" } } }`,
+		"price < list_price",
+		"price < list_price",
+		"",
+	},
+	{
+		`{ "field": "json:cfg.a", "filter":"equal", "value":{ "field":"json:cfg.b" } }`,
+		"json:cfg.a = json:cfg.b",
+		"(\"cfg\"->'a')::text = (\"cfg\"->'b')::text",
+		"",
+	},
+}
+
+func TestFieldReference(t *testing.T) {
+	for _, line := range referenceCases {
+		format, err := FromJSON([]byte(line[0]))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", line[0], err)
+			continue
+		}
+
+		for i, db := range []DBDriver{MySQL{}, &PostgreSQL{}} {
+			sql, vals, err := GetSQL(format, nil, db)
+			if err != nil {
+				t.Errorf("can't generate sql\nj: %s\n%f", line[0], err)
+				continue
+			}
+			if sql != line[i+1] {
+				t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", line[0], line[i+1], sql)
+				continue
+			}
+
+			valsStr, err := anyToStringArray(vals)
+			if err != nil {
+				t.Errorf("can't convert parameters\nj: %s\n%f", line[0], err)
+				continue
+			}
+
+			if valsStr != line[3] {
+				t.Errorf("wrong sql generated (values)\nj: %s\ns: %s\nr: %s", line[0], line[3], valsStr)
+			}
+		}
+	}
+
+	config := &SQLConfig{Whitelist: map[string]bool{"price": true}}
+	for _, text := range []string{
+		`{ "field": "price", "filter":"less", "value":{ "field":"secret" } }`,
+		`{ "field": "price", "filter":"contains", "value":{ "field":"price" } }`,
+	} {
+		format, _ := FromJSON([]byte(text))
+		if _, _, err := GetSQL(format, config); err == nil {
+			t.Errorf("doesn't return error for a wrong field reference\nj: %s", text)
+		}
+	}
+}
+
+func TestAliases(t *testing.T) {
+	config := &SQLConfig{
+		Whitelist: map[string]bool{"customerName": true, "options": true, "created": true},
+		Aliases: map[string]string{
+			"customerName": "CONCAT(c.first, ' ', c.last)",
+			"options":      "json:cfg.a",
+			"created":      "c.created_at",
+		},
+	}
+
+	text := `{ "glue":"and", "rules":[{ "field": "customerName", "filter":"beginsWith", "value":"A" }, { "field": "options", "filter":"equal", "value":1 }, { "field": "created", "filter":"less", "value":{ "field":"created" } }]}`
+	format, err := FromJSON([]byte(text))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", text, err)
+		return
+	}
+
+	sql, _, err := GetSQL(format, config, &PostgreSQL{})
+	if err != nil {
+		t.Errorf("can't generate sql\nj: %s\n%f", text, err)
+		return
+	}
+
+	check := "( CONCAT(c.first, ' ', c.last) LIKE $1 || '%' AND (\"cfg\"->'a')::text = $2 AND c.created_at < c.created_at )"
+	if sql != check {
+		t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", text, check, sql)
+	}
+
+	text = `{ "field": "c.full_name", "filter":"equal", "value":"A" }`
+	format, _ = FromJSON([]byte(text))
+	if _, _, err = GetSQL(format, config); err == nil {
+		t.Errorf("doesn't return error when the physical column is used\nj: %s", text)
+	}
+}
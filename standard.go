@@ -0,0 +1,249 @@
+package querysql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// standardPredicates contains the SQL templates of predicates for each dialect,
+// the templates receive the field prepared by the kind of the predicate
+var standardPredicates = map[string]map[string]string{
+	DialectMySQL: {
+		"year":    "YEAR(%s)",
+		"quarter": "QUARTER(%s)",
+		"month":   "MONTH(%s)",
+		"day":     "DAYOFMONTH(%s)",
+		"weekday": "(WEEKDAY(%s) + 1)",
+		"hour":    "HOUR(%s)",
+		"date":    "DATE(%s)",
+		"lower":   "LOWER(%s)",
+		"upper":   "UPPER(%s)",
+		"trim":    "TRIM(%s)",
+		"length":  "CHAR_LENGTH(%s)",
+		"abs":     "ABS(%s)",
+	},
+	DialectPostgreSQL: {
+		"year":    "EXTRACT(YEAR FROM %s)",
+		"quarter": "EXTRACT(QUARTER FROM %s)",
+		"month":   "EXTRACT(MONTH FROM %s)",
+		"day":     "EXTRACT(DAY FROM %s)",
+		"weekday": "EXTRACT(ISODOW FROM %s)",
+		"hour":    "EXTRACT(HOUR FROM %s)",
+		"date":    "CAST(%s AS DATE)",
+		"lower":   "LOWER(%s)",
+		"upper":   "UPPER(%s)",
+		"trim":    "TRIM(%s)",
+		"length":  "LENGTH(%s)",
+		"abs":     "ABS(%s)",
+	},
+}
+
+// kinds of arguments, they define how JSON fields are converted
+const (
+	argDate   = "date"
+	argNumber = "number"
+	argString = "string"
+	argAny    = "any"
+)
+
+var standardArgs = map[string]string{
+	"year":    argDate,
+	"quarter": argDate,
+	"month":   argDate,
+	"day":     argDate,
+	"weekday": argDate,
+	"hour":    argDate,
+	"date":    argDate,
+	"length":  argString,
+	"abs":     argNumber,
+	"lower":   argAny,
+	"upper":   argAny,
+	"trim":    argAny,
+}
+
+var (
+	datePart = PredicateType{Accepts: []string{TypeDate}, Output: TypeNumber}
+	textPart = PredicateType{Accepts: []string{TypeText}, Output: TypeText}
+)
+
+var standardTypes = map[string]PredicateType{
+	"year":     datePart,
+	"quarter":  datePart,
+	"month":    datePart,
+	"day":      datePart,
+	"weekday":  datePart,
+	"hour":     datePart,
+	"date":     {Accepts: []string{TypeDate}, Output: TypeDate},
+	"lower":    textPart,
+	"upper":    textPart,
+	"trim":     textPart,
+	"length":   {Accepts: []string{TypeText}, Output: TypeNumber},
+	"abs":      {Accepts: []string{TypeNumber}, Output: TypeNumber},
+	"trunc":    {Accepts: []string{TypeDate}, Output: TypeDate},
+	"mod":      {Accepts: []string{TypeNumber}, Output: TypeNumber},
+	"coalesce": {},
+}
+
+// truncUnits contains templates of date truncation, units are validated and inlined into SQL
+var truncUnits = map[string]map[string]string{
+	DialectMySQL: {
+		"year":  "CAST(DATE_FORMAT(%s, '%%Y-01-01') AS DATETIME)",
+		"month": "CAST(DATE_FORMAT(%s, '%%Y-%%m-01') AS DATETIME)",
+		"week":  "CAST(STR_TO_DATE(DATE_FORMAT(%s, '%%x%%v Monday'), '%%x%%v %%W') AS DATETIME)",
+		"day":   "CAST(DATE(%s) AS DATETIME)",
+		"hour":  "CAST(DATE_FORMAT(%s, '%%Y-%%m-%%d %%H:00:00') AS DATETIME)",
+	},
+	DialectPostgreSQL: {
+		"year":  "DATE_TRUNC('year', %s)",
+		"month": "DATE_TRUNC('month', %s)",
+		"week":  "DATE_TRUNC('week', %s)",
+		"day":   "DATE_TRUNC('day', %s)",
+		"hour":  "DATE_TRUNC('hour', %s)",
+	},
+}
+
+// AddStandardPredicates registers the predicates year, quarter, month, day, weekday (1 is Monday),
+// hour, date, lower, upper, trim, length, abs and coalesce for MySQL and PostgreSQL,
+// and the predicates with arguments trunc (year, month, week, day or hour), mod (divisor)
+// and coalesce (default value). Predicates which are already defined in config.Dialects are not replaced.
+// json: fields are supported only by PostgreSQL, MySQL predicates reject them.
+func AddStandardPredicates(config *SQLConfig) {
+	if config.Dialects == nil {
+		config.Dialects = make(map[string]DialectConfig)
+	}
+	if config.PredicateTypes == nil {
+		config.PredicateTypes = make(map[string]PredicateType)
+	}
+	for name, pt := range standardTypes {
+		if _, ok := config.PredicateTypes[name]; !ok {
+			config.PredicateTypes[name] = pt
+		}
+	}
+
+	for dialect, templates := range standardPredicates {
+		d := config.Dialects[dialect]
+		if d.Predicates == nil {
+			d.Predicates = make(map[string]RulePredicate)
+		}
+
+		predicates := map[string]RulePredicate{
+			"coalesce": coalescePredicate,
+			"trunc":    truncPredicate,
+			"mod":      modPredicate,
+		}
+		for name, template := range templates {
+			predicates[name] = standardPredicate(template, standardArgs[name])
+		}
+
+		for name, pr := range predicates {
+			if _, ok := d.Predicates[name]; !ok {
+				if dialect == DialectMySQL {
+					pr = mysqlPredicate(pr)
+				}
+				d.Predicates[name] = pr
+			}
+		}
+
+		config.Dialects[dialect] = d
+	}
+}
+
+func standardPredicate(template, kind string) RulePredicate {
+	return func(rc *RenderContext, p PredicateCall) (string, []interface{}, error) {
+		if len(p.Args) > 0 {
+			return "", nil, ruleError(ErrBadValue, p.Rule.Field, "predicate %s doesn't accept arguments", p.Name)
+		}
+		return fmt.Sprintf(template, standardArg(rc, p, kind)), NoValues, nil
+	}
+}
+
+func standardArg(rc *RenderContext, p PredicateCall, kind string) string {
+	if p.IsJSON && rc.DB.Dialect() == DialectPostgreSQL {
+		return pgJSONArg(p.Field, kind)
+	}
+	return p.Field
+}
+
+// mysqlPredicate rejects json: fields, which are not converted by the MySQL driver,
+// instead of rendering them as column names
+func mysqlPredicate(pr RulePredicate) RulePredicate {
+	return func(rc *RenderContext, p PredicateCall) (string, []interface{}, error) {
+		if strings.Contains(p.Field, "json:") {
+			return "", nil, ruleError(ErrBadRule, p.Rule.Field, "predicate %s doesn't support json fields in MySQL: %s", p.Name, p.Rule.Field)
+		}
+		return pr(rc, p)
+	}
+}
+
+// truncPredicate truncates the date to the unit from the first argument
+func truncPredicate(rc *RenderContext, p PredicateCall) (string, []interface{}, error) {
+	var unit string
+	if len(p.Args) == 1 {
+		unit, _ = p.Args[0].(string)
+	}
+
+	template, ok := truncUnits[rc.DB.Dialect()][unit]
+	if !ok {
+		return "", nil, ruleError(ErrBadValue, p.Rule.Field, "trunc predicate expects year, month, week, day or hour, got %v", p.Args)
+	}
+	return fmt.Sprintf(template, standardArg(rc, p, argDate)), NoValues, nil
+}
+
+// modPredicate returns the remainder of division by the first argument
+func modPredicate(rc *RenderContext, p PredicateCall) (string, []interface{}, error) {
+	if len(p.Args) != 1 {
+		return "", nil, ruleError(ErrBadValue, p.Rule.Field, "mod predicate expects a single argument")
+	}
+	if _, ok := coerceValue(p.Args[0], TypeNumber); !ok {
+		return "", nil, ruleError(ErrBadValue, p.Rule.Field, "mod predicate expects a number, got %v", p.Args[0])
+	}
+
+	return fmt.Sprintf("MOD(%s, %s)", standardArg(rc, p, argNumber), rc.Mark()), p.Args, nil
+}
+
+// pgJSONArg converts the text of a JSONB value, which is quoted by the driver, to the type of the argument
+func pgJSONArg(field, kind string) string {
+	if kind == argAny || !strings.HasSuffix(field, "::text") {
+		return field
+	}
+
+	text := fmt.Sprintf("(%s #>> '{}')", strings.TrimSuffix(field, "::text"))
+	switch kind {
+	case argDate:
+		return fmt.Sprintf("CAST(%s AS TIMESTAMP)", text)
+	case argNumber:
+		return fmt.Sprintf("CAST(%s AS NUMERIC)", text)
+	}
+	return text
+}
+
+// coalescePredicate replaces NULL with the argument or with the empty value of the field type,
+// the type is taken from the schema or from the type of the rule. Date fields and fields
+// of unknown type require the argument, as the empty value can't be chosen for them.
+func coalescePredicate(rc *RenderContext, p PredicateCall) (string, []interface{}, error) {
+	if len(p.Args) > 1 {
+		return "", nil, ruleError(ErrBadValue, p.Rule.Field, "coalesce predicate expects a single argument")
+	}
+	if len(p.Args) == 1 {
+		return fmt.Sprintf("COALESCE(%s, %s)", p.Field, rc.Mark()), p.Args, nil
+	}
+
+	tp := p.Rule.Type
+	if fs, ok := getFieldSchema(p.Rule.Field, rc.Config); ok && fs.Type != "" {
+		tp = fs.Type
+	}
+
+	var empty string
+	switch tp {
+	case TypeNumber:
+		empty = "0"
+	case TypeBoolean:
+		empty = "FALSE"
+	case TypeText:
+		empty = "''"
+	default:
+		return "", nil, ruleError(ErrBadRule, p.Rule.Field, "coalesce predicate requires a default value for field %s", p.Rule.Field)
+	}
+
+	return fmt.Sprintf("COALESCE(%s, %s)", p.Field, empty), NoValues, nil
+}
@@ -0,0 +1,90 @@
+package querysql
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStandardPredicates(t *testing.T) {
+	config := &SQLConfig{
+		Schema: map[string]FieldSchema{
+			"d":     {Type: TypeDate},
+			"name":  {Type: TypeText},
+			"count": {Type: TypeNumber, Nullable: true},
+			"start": {Type: TypeDate},
+		},
+		Aliases: map[string]string{"start": "json:cfg.start", "name": "json:cfg.name"},
+	}
+	AddStandardPredicates(config)
+
+	checks := []struct {
+		json  string
+		mysql string
+		pg    string
+	}{
+		{`{ "field": "d", "predicate":"year", "filter":"equal", "value":2024 }`, "YEAR(d) = ?", "EXTRACT(YEAR FROM d) = $1"},
+		{`{ "field": "d", "predicate":"weekday", "filter":"less", "value":6 }`, "(WEEKDAY(d) + 1) < ?", "EXTRACT(ISODOW FROM d) < $1"},
+		{`{ "field": "d", "predicate":"date", "filter":"equal", "value":"2024-01-01" }`, "DATE(d) = ?", "CAST(d AS DATE) = $1"},
+		{`{ "field": "d", "filter":"equal", "value":"2024-01-01" }`, "d = ?", "d = $1"},
+		{`{ "field": "count", "predicate":"coalesce", "filter":"equal", "value":0 }`, "COALESCE(count, 0) = ?", "COALESCE(count, 0) = $1"},
+		{`{ "field": "start", "predicate":"month", "filter":"equal", "value":5 }`,
+			"", "EXTRACT(MONTH FROM CAST(((\"cfg\"->'start') #>> '{}') AS TIMESTAMP)) = $1"},
+		{`{ "field": "name", "predicate":"length", "filter":"greater", "value":5 }`,
+			"", "LENGTH(((\"cfg\"->'name') #>> '{}')) > $1"},
+		{`{ "field": "name", "predicate":"lower", "filter":"contains", "value":"x" }`,
+			"", "LOWER((\"cfg\"->'name')::text) LIKE '\"%' || $1 || '%\"'"},
+	}
+
+	for _, c := range checks {
+		format, err := FromJSON([]byte(c.json))
+		if err != nil {
+			t.Errorf("can't parse json\nj: %s\n%f", c.json, err)
+			continue
+		}
+
+		for _, db := range []DBDriver{MySQL{}, PostgreSQL{}} {
+			check := c.mysql
+			if db.Dialect() == DialectPostgreSQL {
+				check = c.pg
+			}
+
+			sql, _, err := GetSQL(format, config, db)
+			if check == "" {
+				// MySQL doesn't support json: fields
+				if !errors.Is(err, ErrBadRule) {
+					t.Errorf("json field is not rejected\nj: %s\nr: %s %v", c.json, sql, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("can't generate sql\nj: %s\n%s", c.json, err)
+				continue
+			}
+			if sql != check {
+				t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", c.json, check, sql)
+			}
+		}
+	}
+
+	untyped := &SQLConfig{}
+	AddStandardPredicates(untyped)
+
+	for _, text := range []string{
+		`{ "field": "d", "predicate":"coalesce", "filter":"equal", "value":"2024-01-01" }`,
+		`{ "field": "n", "predicate":"coalesce", "filter":"equal", "value":1 }`,
+	} {
+		format, _ := FromJSON([]byte(text))
+		c := config
+		if format.Field == "n" {
+			c = untyped
+		}
+		if _, _, err := GetSQL(format, c, PostgreSQL{}); !errors.Is(err, ErrBadRule) {
+			t.Errorf("coalesce without default value is accepted\nj: %s\nr: %v", text, err)
+		}
+	}
+
+	format, _ := FromJSON([]byte(`{ "field": "n", "type":"number", "predicate":"coalesce", "filter":"equal", "value":1 }`))
+	if sql, _, err := GetSQL(format, untyped, PostgreSQL{}); err != nil || sql != "COALESCE(n, 0) = $1" {
+		t.Errorf("type of the rule is not used by coalesce\nr: %s %v", sql, err)
+	}
+}
@@ -0,0 +1,159 @@
+package querysql
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// FromStruct builds SQLConfig from the tags of a struct, only fields with the querysql tag are allowed
+//
+//	type User struct {
+//		Name    string     `db:"full_name" querysql:"name,ops=equal|contains"`
+//		Created *time.Time `db:"created_at" querysql:"created,preds=year|month,label=Created at"`
+//		Region  int        `querysql:",max=10"`
+//	}
+//
+// The first part of the tag is the field name used by the client, it defaults to the db tag
+// and then to the name of the struct field. The db tag is used as the column name.
+// Options: type=text|number|date|boolean, ops=..., preds=..., null, max=N, label=...
+// Field type is derived from the Go type when not set, pointers and sql.Null* types are nullable.
+// Only builtin operations can be listed in ops, custom ones are added to FieldSchema.Filters of the result.
+func FromStruct(v interface{}) (*SQLConfig, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("struct expected, got %T", v)
+	}
+
+	config := &SQLConfig{
+		Schema:  make(map[string]FieldSchema),
+		Aliases: make(map[string]string),
+	}
+
+	if err := readStruct(t, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func readStruct(t reflect.Type, config *SQLConfig) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag, ok := f.Tag.Lookup("querysql")
+		if !ok {
+			if f.Anonymous && indirect(f.Type).Kind() == reflect.Struct {
+				if err := readStruct(indirect(f.Type), config); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		column := strings.Split(f.Tag.Get("db"), ",")[0]
+		if column == "-" {
+			column = ""
+		}
+
+		name := parts[0]
+		if name == "" {
+			name = column
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := FieldSchema{}
+		fs.Type, fs.Nullable = goFieldType(f.Type)
+
+		for _, opt := range parts[1:] {
+			key, value := opt, ""
+			if eq := strings.Index(opt, "="); eq != -1 {
+				key, value = opt[:eq], opt[eq+1:]
+			}
+
+			switch key {
+			case "type":
+				if _, ok := typeFilters[value]; !ok && value != TypeText {
+					return fmt.Errorf("unknown type of field %s: %s", f.Name, value)
+				}
+				fs.Type = value
+			case "ops":
+				fs.Filters = strings.Split(value, "|")
+				for _, op := range fs.Filters {
+					if !contains(builtinFilters, op) {
+						return fmt.Errorf("unknown operation of field %s: %s", f.Name, op)
+					}
+				}
+			case "preds":
+				fs.Predicates = strings.Split(value, "|")
+			case "null":
+				fs.Nullable = true
+			case "max":
+				max, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("wrong max option of field %s: %s", f.Name, value)
+				}
+				fs.MaxIncludes = max
+			case "label":
+				fs.Label = value
+			default:
+				return fmt.Errorf("unknown option of field %s: %s", f.Name, key)
+			}
+		}
+
+		config.Schema[name] = fs
+		if column != "" && column != name {
+			config.Aliases[name] = column
+		}
+	}
+
+	return nil
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+func goFieldType(t reflect.Type) (string, bool) {
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	// sql.NullString, sql.NullInt64, ... keep the value in the first field
+	if t.PkgPath() == "database/sql" && strings.HasPrefix(t.Name(), "Null") && t.NumField() > 0 {
+		t = t.Field(0).Type
+		nullable = true
+	}
+
+	if t == timeType {
+		return TypeDate, nullable
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return TypeBoolean, nullable
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return TypeNumber, nullable
+	}
+
+	return TypeText, nullable
+}
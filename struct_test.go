@@ -0,0 +1,85 @@
+package querysql
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tagBase struct {
+	ID int `db:"id" querysql:"id"`
+}
+
+type tagUser struct {
+	tagBase
+	Name     string         `db:"full_name" querysql:"name,ops=equal|contains"`
+	Created  *time.Time     `db:"created_at" querysql:"created,preds=year|month,label=Created at"`
+	Region   int            `querysql:",max=10"`
+	Active   sql.NullBool   `db:"is_active" querysql:""`
+	Password string         `db:"password"`
+	Note     sql.NullString `db:"note" querysql:"-"`
+}
+
+func TestFromStruct(t *testing.T) {
+	config, err := FromStruct(&tagUser{})
+	if err != nil {
+		t.Errorf("can't build config\n%s", err)
+		return
+	}
+
+	schema := map[string]FieldSchema{
+		"id":        {Type: TypeNumber},
+		"name":      {Type: TypeText, Filters: []string{"equal", "contains"}},
+		"created":   {Type: TypeDate, Nullable: true, Predicates: []string{"year", "month"}, Label: "Created at"},
+		"Region":    {Type: TypeNumber, MaxIncludes: 10},
+		"is_active": {Type: TypeBoolean, Nullable: true},
+	}
+	if !reflect.DeepEqual(config.Schema, schema) {
+		t.Errorf("wrong schema generated\ns: %+v\nr: %+v", schema, config.Schema)
+	}
+
+	aliases := map[string]string{"name": "full_name", "created": "created_at"}
+	if !reflect.DeepEqual(config.Aliases, aliases) {
+		t.Errorf("wrong aliases generated\ns: %+v\nr: %+v", aliases, config.Aliases)
+	}
+
+	text := `{ "glue":"and", "rules":[{ "field": "name", "filter":"contains", "value":"a" }, { "field": "Region", "filter":"equal", "value":"5" }, { "field": "created", "filter":"less", "value":"2024-01-02" }]}`
+	format, _ := FromJSON([]byte(text))
+	sql, vals, err := GetSQL(format, config)
+	if err != nil {
+		t.Errorf("can't generate sql\nj: %s\n%s", text, err)
+		return
+	}
+
+	check := "( INSTR(full_name, ?) > 0 AND Region = ? AND created_at < ? )"
+	if sql != check {
+		t.Errorf("wrong sql generated\nj: %s\ns: %s\nr: %s", text, check, sql)
+	}
+	if vals[1] != 5.0 || vals[2] != time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("values are not converted\nj: %s\nr: %+v", text, vals)
+	}
+
+	for _, text := range []string{
+		`{ "field": "password", "filter":"equal", "value":"a" }`,
+		`{ "field": "name", "filter":"beginsWith", "value":"a" }`,
+		`{ "field": "note", "filter":"equal", "value":"a" }`,
+	} {
+		format, _ := FromJSON([]byte(text))
+		if _, _, err := GetSQL(format, config); err == nil {
+			t.Errorf("doesn't return error for a field outside of the struct config\nj: %s", text)
+		}
+	}
+
+	if _, err := FromStruct(struct {
+		A int `querysql:"a,type=money"`
+	}{}); err == nil {
+		t.Errorf("doesn't return error for unknown type")
+	}
+
+	if _, err := FromStruct(struct {
+		A int `querysql:"a,ops=equals|between"`
+	}{}); err == nil {
+		t.Errorf("doesn't return error for unknown operation")
+	}
+}
@@ -0,0 +1,100 @@
+package querysql
+
+import (
+	"encoding/json"
+)
+
+var errorCodes = map[error]string{
+	ErrFieldNotAllowed:     "fieldNotAllowed",
+	ErrUnknownOperation:    "unknownOperation",
+	ErrOperationNotAllowed: "operationNotAllowed",
+	ErrUnknownPredicate:    "unknownPredicate",
+	ErrPredicateNotAllowed: "predicateNotAllowed",
+	ErrUnknownRelation:     "unknownRelation",
+	ErrBadValue:            "badValue",
+	ErrBadRule:             "badRule",
+	ErrLimitExceeded:       "limitExceeded",
+}
+
+// MarshalJSON allows to return validation errors as the response body
+func (e *RuleError) MarshalJSON() ([]byte, error) {
+	code, ok := errorCodes[e.Err]
+	if !ok {
+		code = "invalidRule"
+	}
+
+	return json.Marshal(struct {
+		Path    string `json:"path"`
+		Field   string `json:"field,omitempty"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{e.Path, e.Field, code, e.Error()})
+}
+
+// Validate checks all rules of the filter without stopping at the first error,
+// every returned error is a *RuleError. The result is nil when the filter is valid.
+// The driver selects the hooks of SQLConfig.Dialects, MySQL is used by default.
+// Policies are applied for the anonymous principal, see ValidateFor.
+func Validate(data Filter, config *SQLConfig, dbArr ...DBDriver) []error {
+	return ValidateFor(nil, data, config, dbArr...)
+}
+
+// ValidateFor checks the filter like Validate, denied fields and operations
+// of policies are applied for the principal in the same way as by GetSQLFor
+func ValidateFor(p *Principal, data Filter, config *SQLConfig, dbArr ...DBDriver) []error {
+	// the tree is not walked when it is too large
+	if err := checkLimits(data, config); err != nil {
+		return []error{err}
+	}
+
+	var db DBDriver
+	if len(dbArr) > 0 {
+		db = dbArr[0]
+	} else {
+		db = MySQL{}
+	}
+
+	// scopes are trusted, so only the restrictions of policies are needed
+	rc := NewRenderContext(config, db)
+	rc.Principal = p
+	if _, err := rc.loadPolicies(); err != nil {
+		return []error{withPath(err, "", "")}
+	}
+
+	return validate(data, config, rc, "", nil)
+}
+
+func validate(data Filter, config *SQLConfig, base *RenderContext, path string, errs []error) []error {
+	if data.Relation != "" && data.Aggregate == "" {
+		rel, ok := getRelation(data.Relation, config)
+		if !ok {
+			return append(errs, &RuleError{Path: path, Field: data.Field, Err: ErrUnknownRelation, Detail: data.Relation})
+		}
+		if err := checkRelation(data, rel); err != nil {
+			return append(errs, withPath(err, path, data.Field))
+		}
+
+		nested := data
+		nested.Relation = ""
+		return validate(nested, rel.Config, base, path, errs)
+	}
+
+	if data.Rules == nil || data.Relation != "" {
+		// rendering of a single rule performs all checks
+		rc := *base
+		rc.Config = config
+		if _, _, err := getSQL(data, config, &rc, path); err != nil {
+			errs = append(errs, withPath(err, path, data.Field))
+		}
+		return errs
+	}
+
+	for i, r := range data.Rules {
+		subPath := childPath(path, i)
+		if r.isolated {
+			subPath = ""
+		}
+		errs = validate(r, config, base, subPath, errs)
+	}
+	return errs
+}
@@ -0,0 +1,103 @@
+package querysql
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	config := &SQLConfig{
+		Schema: map[string]FieldSchema{
+			"a": {Type: TypeNumber},
+			"b": {Type: TypeText},
+		},
+		Relations: map[string]Relation{"items": {Table: "items", Config: &SQLConfig{Whitelist: map[string]bool{"sku": true}}}},
+	}
+
+	text := `{ "glue":"and", "rules":[
+		{ "field": "a", "filter":"less", "value":1 },
+		{ "field": "x", "filter":"less", "value":1 },
+		{ "glue":"or", "rules":[{ "field": "a", "filter":"between", "value":5 }, { "field": "b", "filter":"like", "value":"a" }]},
+		{ "field": "a", "filter":"equal", "value":"abc" },
+		{ "relation":"items", "rules":[{ "field": "sku", "filter":"equal", "value":1 }, { "field": "a", "filter":"equal", "value":1 }]},
+		{ "field": "b", "filter":"between", "value":{} }
+	]}`
+	format, err := FromJSON([]byte(text))
+	if err != nil {
+		t.Errorf("can't parse json\nj: %s\n%f", text, err)
+		return
+	}
+
+	errs := Validate(format, config)
+	check := []struct {
+		path string
+		err  error
+	}{
+		{"rules[1]", ErrFieldNotAllowed},
+		{"rules[2].rules[0]", ErrBadValue},
+		{"rules[2].rules[1]", ErrUnknownOperation},
+		{"rules[3]", ErrBadValue},
+		{"rules[4].rules[1]", ErrFieldNotAllowed},
+		{"rules[5]", ErrBadValue},
+	}
+
+	if len(errs) != len(check) {
+		t.Errorf("wrong number of errors\ns: %d\nr: %v", len(check), errs)
+		return
+	}
+
+	for i, c := range check {
+		var re *RuleError
+		if !errors.Is(errs[i], c.err) || !errors.As(errs[i], &re) || re.Path != c.path {
+			t.Errorf("wrong error\ns: %s %s\nr: %s", c.path, c.err, errs[i])
+		}
+	}
+
+	out, _ := json.Marshal(errs[0])
+	if string(out) != `{"path":"rules[1]","field":"x","code":"fieldNotAllowed","message":"rules[1]: field name is not in whitelist: x"}` {
+		t.Errorf("wrong json of the error\nr: %s", out)
+	}
+
+	format, _ = FromJSON([]byte(aAndB))
+	if errs := Validate(format, nil); errs != nil {
+		t.Errorf("returns errors for a valid filter\nr: %v", errs)
+	}
+}
+
+func TestValidatePolicies(t *testing.T) {
+	config := &SQLConfig{
+		Whitelist: map[string]bool{"a": true, "salary": true},
+		Policies: []Policy{func(p *Principal) (PolicyResult, error) {
+			if p.HasRole("manager") {
+				return PolicyResult{}, nil
+			}
+			return PolicyResult{
+				Scopes:         []Filter{Field("region").Equal(1)},
+				DenyFields:     []string{"salary"},
+				DenyOperations: []string{"contains"},
+			}, nil
+		}},
+	}
+
+	text := `{ "glue":"and", "rules":[{ "field": "a", "filter":"contains", "value":"x" }, { "field": "salary", "filter":"less", "value":1 }]}`
+	format, _ := FromJSON([]byte(text))
+
+	errs := Validate(format, config)
+	if len(errs) != 2 || !errors.Is(errs[0], ErrOperationNotAllowed) || !errors.Is(errs[1], ErrFieldNotAllowed) {
+		t.Errorf("policies are not applied\nj: %s\nr: %v", text, errs)
+	}
+
+	var re *RuleError
+	if len(errs) == 2 && (!errors.As(errs[1], &re) || re.Path != "rules[1]") {
+		t.Errorf("wrong path of the error\nr: %v", errs[1])
+	}
+
+	if _, _, err := GetSQLFor(nil, format, config); err == nil {
+		t.Errorf("GetSQLFor doesn't agree with Validate")
+	}
+
+	if errs := ValidateFor(&Principal{Roles: []string{"manager"}}, format, config); errs != nil {
+		t.Errorf("policies are applied for manager\nr: %v", errs)
+	}
+}
@@ -0,0 +1,66 @@
+package querysql
+
+import (
+	"sort"
+)
+
+// QueryField is a field definition of the Webix query builder.
+// Conditions is always present, an empty list means the field is filtered only by Options.
+type QueryField struct {
+	ID         string   `json:"id"`
+	Value      string   `json:"value"`
+	Type       string   `json:"type"`
+	Conditions []string `json:"conditions"`
+	Predicates []string `json:"predicates,omitempty"`
+	Options    []Option `json:"options,omitempty"`
+}
+
+// QueryBuilderFields converts the schema to the field list of the Webix query builder,
+// fields are sorted by name
+func QueryBuilderFields(schema map[string]FieldSchema) []QueryField {
+	out := make([]QueryField, 0, len(schema))
+	for id, fs := range schema {
+		label := fs.Label
+		if label == "" {
+			label = id
+		}
+
+		tp := fs.Type
+		if tp == "" {
+			tp = TypeText
+		}
+
+		out = append(out, QueryField{
+			ID:         id,
+			Value:      label,
+			Type:       tp,
+			Conditions: fs.conditions(),
+			Predicates: fs.Predicates,
+			Options:    fs.Options,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// conditions lists the operations available for the field,
+// includes is not a condition of the query builder and is represented by Options
+func (fs FieldSchema) conditions() []string {
+	filters := fs.Filters
+	if filters == nil {
+		if allowed, ok := typeFilters[fs.Type]; ok {
+			filters = allowed
+		} else {
+			filters = builtinFilters
+		}
+	}
+
+	out := make([]string, 0, len(filters))
+	for _, f := range filters {
+		if f != "includes" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,30 @@
+package querysql
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestQueryBuilderFields(t *testing.T) {
+	fields := QueryBuilderFields(map[string]FieldSchema{
+		"region": {Type: TypeNumber, Label: "Region", Options: []Option{{1, "North"}, {2, "South"}}},
+		"name":   {Filters: []string{"equal", "contains", "includes"}},
+		"active": {Type: TypeBoolean, Predicates: []string{"not"}},
+		"status": {Filters: []string{"includes"}, Options: []Option{{"new", "New"}}},
+	})
+
+	text, err := json.Marshal(fields)
+	if err != nil {
+		t.Errorf("can't convert fields to json\n%s", err)
+		return
+	}
+
+	check := `[{"id":"active","value":"active","type":"boolean","conditions":["equal","notEqual"],"predicates":["not"]},` +
+		`{"id":"name","value":"name","type":"text","conditions":["equal","contains"]},` +
+		`{"id":"region","value":"Region","type":"number","conditions":["equal","notEqual","less","lessOrEqual","greater","greaterOrEqual","between","notBetween"],` +
+		`"options":[{"id":1,"value":"North"},{"id":2,"value":"South"}]},` +
+		`{"id":"status","value":"status","type":"text","conditions":[],"options":[{"id":"new","value":"New"}]}]`
+	if string(text) != check {
+		t.Errorf("wrong fields generated\ns: %s\nr: %s", check, text)
+	}
+}